@@ -0,0 +1,61 @@
+// Command notary-schema writes JSON Schemas for the NotaryOS SDK wire types.
+//
+// Usage:
+//
+//	go run github.com/hellothere012/notaryos-go/cmd/notary-schema -out schemas/
+//
+// Without -out, all schemas are printed to stdout as one JSON object keyed by
+// type name.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hellothere012/notaryos-go/notary"
+)
+
+func main() {
+	out := flag.String("out", "", "directory to write <Type>.schema.json files into")
+	flag.Parse()
+
+	schemas := notary.PublishedSchemas()
+
+	if *out == "" {
+		data, err := json.MarshalIndent(schemas, "", "  ")
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fail(err)
+	}
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := json.MarshalIndent(schemas[name], "", "  ")
+		if err != nil {
+			fail(err)
+		}
+		path := filepath.Join(*out, name+".schema.json")
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			fail(err)
+		}
+		fmt.Println(path)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "notary-schema:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,294 @@
+package notary
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RetentionRule keeps receipts whose action type matches ActionTypes for at
+// least Keep after they were issued.
+type RetentionRule struct {
+	// ActionTypes are exact types, "ns.*" namespaces, or "*".
+	ActionTypes []string
+	Keep        time.Duration
+}
+
+// ArchiveBundle is the audit export written by an Archiver. MerkleRoot
+// commits to every receipt in order, so the bundle can be checked against
+// the archival receipt long after the originals are gone from the server.
+type ArchiveBundle struct {
+	Version    int              `json:"version"`
+	CreatedAt  time.Time        `json:"created_at"`
+	MerkleRoot string           `json:"merkle_root"`
+	Count      int              `json:"count"`
+	Receipts   []map[string]any `json:"receipts"`
+}
+
+// Verify recomputes the Merkle root over the bundle's receipts.
+func (b *ArchiveBundle) Verify() error {
+	if len(b.Receipts) != b.Count {
+		return fmt.Errorf("archive bundle holds %d receipts, header says %d", len(b.Receipts), b.Count)
+	}
+	root, err := MerkleRoot(b.Receipts)
+	if err != nil {
+		return err
+	}
+	if root != b.MerkleRoot {
+		return fmt.Errorf("archive bundle Merkle root mismatch: computed %s, recorded %s", root, b.MerkleRoot)
+	}
+	return nil
+}
+
+// ReadArchiveBundle decodes a bundle and checks its Merkle root.
+func ReadArchiveBundle(r io.Reader) (*ArchiveBundle, error) {
+	var b ArchiveBundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to parse archive bundle: %w", err)
+	}
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// MerkleRoot returns the hex RFC 6962 Merkle tree hash over the canonical
+// JSON of each receipt, in order.
+func MerkleRoot(receipts []map[string]any) (string, error) {
+	leaves := make([][]byte, len(receipts))
+	for i, r := range receipts {
+		data, err := CanonicalJSON(r)
+		if err != nil {
+			return "", fmt.Errorf("receipt %d: %w", i, err)
+		}
+		leaves[i] = data
+	}
+	root := merkleTreeHash(leaves)
+	return hex.EncodeToString(root[:]), nil
+}
+
+func merkleTreeHash(leaves [][]byte) [32]byte {
+	switch len(leaves) {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return sha256.Sum256(append([]byte{0x00}, leaves[0]...))
+	}
+	// Split at the largest power of two smaller than n.
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	left, right := merkleTreeHash(leaves[:k]), merkleTreeHash(leaves[k:])
+	node := make([]byte, 0, 1+64)
+	node = append(node, 0x01)
+	node = append(node, left[:]...)
+	node = append(node, right[:]...)
+	return sha256.Sum256(node)
+}
+
+// ArchiveResult summarizes one Archiver.Run.
+type ArchiveResult struct {
+	// Archived lists the hashes exported to the bundle and marked for deletion.
+	Archived   []string
+	MerkleRoot string
+	// ArchiveReceipt is the receipt recording the bundle.
+	ArchiveReceipt *Receipt
+	// Marked is the number of receipts the server accepted for deletion.
+	Marked int
+	// Failed maps receipts that did not re-verify to the reason. They are
+	// left out of the bundle and never marked for deletion.
+	Failed map[string]string
+}
+
+// Archiver moves receipts past their retention period out of the service:
+// it exports them to an audit bundle, re-verifying each one, records an
+// archival receipt over the bundle's Merkle root, and only then marks them
+// for deletion on the server. Receipts matching no rule are kept.
+//
+//	archiver := notary.NewArchiver(client, store, verifier,
+//	    notary.RetentionRule{ActionTypes: []string{"billing.*"}, Keep: 7 * 365 * 24 * time.Hour},
+//	    notary.RetentionRule{ActionTypes: []string{"*"}, Keep: 365 * 24 * time.Hour},
+//	)
+//	f, _ := os.Create("archive-2026-10.json")
+//	result, err := archiver.Run(f)
+type Archiver struct {
+	client   *Client
+	store    Store
+	verifier *OfflineVerifier
+	rules    []RetentionRule
+	// ActionType of the archival receipt (default "notary.archive").
+	ActionType string
+	// BatchSize caps the hashes per deletion request (default 500).
+	BatchSize int
+	// Now returns the current time (default time.Now).
+	Now func() time.Time
+}
+
+// NewArchiver creates an Archiver reading receipts from store (for example
+// one kept current by a Syncer). Rules are matched in order; the first rule
+// matching a receipt's action type decides its retention. A nil verifier
+// loads the service's keys through client.OfflineVerifier on each Run.
+func NewArchiver(client *Client, store Store, verifier *OfflineVerifier, rules ...RetentionRule) *Archiver {
+	return &Archiver{
+		client:     client,
+		store:      store,
+		verifier:   verifier,
+		rules:      rules,
+		ActionType: "notary.archive",
+		BatchSize:  500,
+		Now:        time.Now,
+	}
+}
+
+// Due returns the stored receipts whose retention period has elapsed. Only
+// receipts older than the shortest Keep are read from the store.
+func (a *Archiver) Due() ([]StoredReceipt, error) {
+	if len(a.rules) == 0 {
+		return nil, nil
+	}
+	now := a.Now()
+	shortest := a.rules[0].Keep
+	for _, rule := range a.rules[1:] {
+		if rule.Keep < shortest {
+			shortest = rule.Keep
+		}
+	}
+	receipts, err := a.store.Query(LocalQuery{Until: now.Add(-shortest)})
+	if err != nil {
+		return nil, err
+	}
+	var due []StoredReceipt
+	for _, r := range receipts {
+		for _, rule := range a.rules {
+			if matchesAny(r.ActionType, rule.ActionTypes) {
+				if !r.Timestamp.IsZero() && now.Sub(r.Timestamp) >= rule.Keep {
+					due = append(due, r)
+				}
+				break
+			}
+		}
+	}
+	return due, nil
+}
+
+// Run archives every due receipt into a bundle written to w. Nothing is
+// marked for deletion unless the bundle was written and the archival
+// receipt issued. Each batch the server accepts is then deleted from the
+// store, so later runs skip it. If marking fails part-way, or the server
+// accepts fewer receipts than a batch holds, the error is returned with the
+// result so far; the batch stays in the store and a later run exports it
+// again.
+func (a *Archiver) Run(w io.Writer) (*ArchiveResult, error) {
+	due, err := a.Due()
+	if err != nil {
+		return nil, err
+	}
+	verifier := a.verifier
+	if verifier == nil {
+		if verifier, err = a.client.OfflineVerifier(); err != nil {
+			return nil, fmt.Errorf("failed to load verification keys: %w", err)
+		}
+	}
+	result := &ArchiveResult{Failed: make(map[string]string)}
+
+	bundle := &ArchiveBundle{Version: 1, CreatedAt: a.Now().UTC()}
+	var oldest, newest time.Time
+	for _, r := range due {
+		check := verifier.Verify(r.Receipt)
+		if !check.Valid {
+			result.Failed[r.ReceiptHash] = check.Reason
+			continue
+		}
+		bundle.Receipts = append(bundle.Receipts, r.Receipt)
+		result.Archived = append(result.Archived, r.ReceiptHash)
+		if oldest.IsZero() || r.Timestamp.Before(oldest) {
+			oldest = r.Timestamp
+		}
+		if r.Timestamp.After(newest) {
+			newest = r.Timestamp
+		}
+	}
+	if len(bundle.Receipts) == 0 {
+		return result, nil
+	}
+	bundle.Count = len(bundle.Receipts)
+	if bundle.MerkleRoot, err = MerkleRoot(bundle.Receipts); err != nil {
+		return result, err
+	}
+	result.MerkleRoot = bundle.MerkleRoot
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode archive bundle: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return result, fmt.Errorf("failed to write archive bundle: %w", err)
+	}
+	bundleSum := sha256.Sum256(data)
+
+	receipt, err := a.client.Issue(a.ActionType, map[string]any{
+		"merkle_root":   bundle.MerkleRoot,
+		"receipt_count": bundle.Count,
+		"bundle_sha256": hex.EncodeToString(bundleSum[:]),
+		"oldest":        oldest.UTC().Format(time.RFC3339),
+		"newest":        newest.UTC().Format(time.RFC3339),
+		"failed_count":  len(result.Failed),
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to issue archival receipt: %w", err)
+	}
+	result.ArchiveReceipt = receipt
+
+	batch := a.BatchSize
+	if batch <= 0 {
+		batch = len(result.Archived)
+	}
+	for start := 0; start < len(result.Archived); start += batch {
+		end := start + batch
+		if end > len(result.Archived) {
+			end = len(result.Archived)
+		}
+		hashes := result.Archived[start:end]
+		marked, err := a.client.MarkForDeletion(hashes, receipt.ReceiptHash)
+		result.Marked += marked
+		if err != nil {
+			return result, err
+		}
+		// The server reports only a count, so a short batch cannot tell
+		// which receipts were refused; keep all of them locally.
+		if marked != len(hashes) {
+			return result, fmt.Errorf("server marked %d of %d archived receipts for deletion; kept the batch in the store", marked, len(hashes))
+		}
+		if _, err := a.store.Delete(hashes); err != nil {
+			return result, fmt.Errorf("failed to remove archived receipts from store: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// MarkForDeletion asks the server to delete receipts that were archived
+// under archiveReceiptHash, and returns how many it accepted. The server
+// keeps the archival receipt, so the deletion itself stays auditable.
+func (c *Client) MarkForDeletion(receiptHashes []string, archiveReceiptHash string) (int, error) {
+	if archiveReceiptHash == "" {
+		return 0, &NotaryError{Message: "archive receipt hash is required", Code: ErrValidationFailed}
+	}
+	respBody, err := c.doRequest("POST", "/receipts/deletions", map[string]any{
+		"receipt_hashes":       receiptHashes,
+		"archive_receipt_hash": archiveReceiptHash,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		Marked int `json:"marked"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return 0, &NotaryError{Message: "failed to parse deletion response", Code: "ERR_PARSE"}
+	}
+	return resp.Marked, nil
+}
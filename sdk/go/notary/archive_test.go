@@ -0,0 +1,108 @@
+package notary_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellothere012/notaryos-go/notary"
+	"github.com/hellothere012/notaryos-go/notarytest"
+)
+
+// archiveFixture issues four old receipts and one recent one, syncs them
+// into a fresh store, and returns an Archiver that keeps receipts a day.
+func archiveFixture(t *testing.T, verifier *notary.OfflineVerifier) (*notarytest.MockServer, *notary.Client, *notary.FileStore, *notary.Archiver) {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := notarytest.NewMockServer(&notarytest.MockConfig{Now: func() time.Time { return now }})
+	t.Cleanup(srv.Close)
+	client, err := notary.NewClient("notary_test_key", &notary.Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := client.Issue("billing.charge", map[string]any{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(48 * time.Hour)
+	if _, err := client.Issue("billing.charge", map[string]any{"n": "recent"}); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := notary.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if _, err := notary.NewSyncer(client, store).Sync(); err != nil {
+		t.Fatal(err)
+	}
+	archiver := notary.NewArchiver(client, store, verifier,
+		notary.RetentionRule{ActionTypes: []string{"*"}, Keep: 24 * time.Hour})
+	archiver.Now = func() time.Time { return now }
+	return srv, client, store, archiver
+}
+
+func TestArchiverRunDeletesArchivedReceipts(t *testing.T) {
+	var verifier notary.OfflineVerifier
+	srv, _, store, archiver := archiveFixture(t, &verifier)
+	verifier.TrustSigner(srv.Signer())
+
+	result, err := archiver.Run(&bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Archived) != 4 || result.Marked != 4 || result.ArchiveReceipt == nil {
+		t.Fatalf("first run: archived %d, marked %d", len(result.Archived), result.Marked)
+	}
+	if n := store.Len(); n != 1 {
+		t.Errorf("store holds %d receipts after archiving, want 1", n)
+	}
+
+	again, err := archiver.Run(&bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Archived) != 0 || again.ArchiveReceipt != nil {
+		t.Errorf("second run re-archived %d receipts", len(again.Archived))
+	}
+}
+
+func TestArchiverRunKeepsShortBatch(t *testing.T) {
+	var verifier notary.OfflineVerifier
+	srv, client, store, archiver := archiveFixture(t, &verifier)
+	verifier.TrustSigner(srv.Signer())
+
+	// Delete one due receipt on the server first, so it is not marked again.
+	due, err := archiver.Due()
+	if err != nil || len(due) != 4 {
+		t.Fatalf("Due = %d receipts, %v", len(due), err)
+	}
+	if _, err := client.MarkForDeletion([]string{due[0].ReceiptHash}, "archive-elsewhere"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := archiver.Run(&bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "marked 3 of 4") {
+		t.Fatalf("Run error = %v, want a short-batch error", err)
+	}
+	if result.Marked != 3 {
+		t.Errorf("Marked = %d, want 3", result.Marked)
+	}
+	if n := store.Len(); n != 5 {
+		t.Errorf("store holds %d receipts, want all 5 kept", n)
+	}
+}
+
+func TestArchiverNilVerifierUsesServiceKeys(t *testing.T) {
+	_, _, _, archiver := archiveFixture(t, nil)
+	result, err := archiver.Run(&bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Archived) != 4 || len(result.Failed) != 0 {
+		t.Errorf("archived %d, failed %v", len(result.Archived), result.Failed)
+	}
+}
@@ -0,0 +1,152 @@
+package notary
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// A compact port of the BLAKE3 reference implementation (hash mode, 32-byte
+// output), kept in-tree so the SDK stays free of external dependencies.
+// Payloads are small, so the portable code is fast enough.
+
+const (
+	blake3ChunkLen   = 1024
+	blake3BlockLen   = 64
+	blake3ChunkStart = 1 << 0
+	blake3ChunkEnd   = 1 << 1
+	blake3Parent     = 1 << 2
+	blake3Root       = 1 << 3
+)
+
+var blake3IV = [8]uint32{
+	0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A,
+	0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19,
+}
+
+var blake3Permutation = [16]int{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8}
+
+func blake3G(s *[16]uint32, a, b, c, d int, mx, my uint32) {
+	s[a] += s[b] + mx
+	s[d] = bits.RotateLeft32(s[d]^s[a], -16)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -12)
+	s[a] += s[b] + my
+	s[d] = bits.RotateLeft32(s[d]^s[a], -8)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -7)
+}
+
+func blake3Compress(cv *[8]uint32, block *[16]uint32, counter uint64, blockLen, flags uint32) [16]uint32 {
+	s := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		blake3IV[0], blake3IV[1], blake3IV[2], blake3IV[3],
+		uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+	m := *block
+	for r := 0; r < 7; r++ {
+		blake3G(&s, 0, 4, 8, 12, m[0], m[1])
+		blake3G(&s, 1, 5, 9, 13, m[2], m[3])
+		blake3G(&s, 2, 6, 10, 14, m[4], m[5])
+		blake3G(&s, 3, 7, 11, 15, m[6], m[7])
+		blake3G(&s, 0, 5, 10, 15, m[8], m[9])
+		blake3G(&s, 1, 6, 11, 12, m[10], m[11])
+		blake3G(&s, 2, 7, 8, 13, m[12], m[13])
+		blake3G(&s, 3, 4, 9, 14, m[14], m[15])
+		if r < 6 {
+			var p [16]uint32
+			for i, j := range blake3Permutation {
+				p[i] = m[j]
+			}
+			m = p
+		}
+	}
+	for i := 0; i < 8; i++ {
+		s[i] ^= s[i+8]
+		s[i+8] ^= cv[i]
+	}
+	return s
+}
+
+func blake3Words(block []byte) [16]uint32 {
+	var padded [blake3BlockLen]byte
+	copy(padded[:], block)
+	var w [16]uint32
+	for i := range w {
+		w[i] = binary.LittleEndian.Uint32(padded[i*4:])
+	}
+	return w
+}
+
+// blake3Output is a node whose chaining value or root output is pending.
+type blake3Output struct {
+	cv       [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (o *blake3Output) chainingValue() [8]uint32 {
+	s := blake3Compress(&o.cv, &o.block, o.counter, o.blockLen, o.flags)
+	var cv [8]uint32
+	copy(cv[:], s[:8])
+	return cv
+}
+
+func (o *blake3Output) root() [32]byte {
+	s := blake3Compress(&o.cv, &o.block, 0, o.blockLen, o.flags|blake3Root)
+	var out [32]byte
+	for i := 0; i < 8; i++ {
+		binary.LittleEndian.PutUint32(out[i*4:], s[i])
+	}
+	return out
+}
+
+// blake3Chunk returns the output node of one chunk (<= 1024 bytes).
+func blake3Chunk(chunk []byte, counter uint64) *blake3Output {
+	cv := blake3IV
+	flags := uint32(blake3ChunkStart)
+	for len(chunk) > blake3BlockLen {
+		block := blake3Words(chunk[:blake3BlockLen])
+		s := blake3Compress(&cv, &block, counter, blake3BlockLen, flags)
+		copy(cv[:], s[:8])
+		chunk = chunk[blake3BlockLen:]
+		flags = 0
+	}
+	return &blake3Output{
+		cv:       cv,
+		block:    blake3Words(chunk),
+		counter:  counter,
+		blockLen: uint32(len(chunk)),
+		flags:    flags | blake3ChunkEnd,
+	}
+}
+
+func blake3ParentOutput(left, right [8]uint32) *blake3Output {
+	o := &blake3Output{cv: blake3IV, blockLen: blake3BlockLen, flags: blake3Parent}
+	copy(o.block[:8], left[:])
+	copy(o.block[8:], right[:])
+	return o
+}
+
+// blake3Sum256 returns the 32-byte BLAKE3 hash of data.
+func blake3Sum256(data []byte) [32]byte {
+	var stack [][8]uint32
+	var counter uint64
+	for len(data) > blake3ChunkLen {
+		cv := blake3Chunk(data[:blake3ChunkLen], counter).chainingValue()
+		data = data[blake3ChunkLen:]
+		counter++
+		// Merge completed subtrees: one merge per trailing zero bit.
+		for total := counter; total&1 == 0; total >>= 1 {
+			cv = blake3ParentOutput(stack[len(stack)-1], cv).chainingValue()
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, cv)
+	}
+	out := blake3Chunk(data, counter)
+	for i := len(stack) - 1; i >= 0; i-- {
+		out = blake3ParentOutput(stack[i], out.chainingValue())
+	}
+	return out.root()
+}
@@ -0,0 +1,207 @@
+package notary
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BloomFilter is a probabilistic set of receipt hashes for cheap local
+// pre-filtering: MayExist never returns false for an added hash, so only
+// hashes it accepts need a Lookup.
+//
+//	filter, _ := client.BloomSnapshot()
+//	for _, h := range candidates {
+//	    if !filter.MayExist(h) {
+//	        continue // definitely not issued
+//	    }
+//	    result, _ := client.Lookup(h)
+//	    ...
+//	}
+//
+// Positions use double hashing over the receipt hash digest: h1 and h2 are
+// the first two big-endian uint64s of the SHA-256 digest (the hex-decoded
+// hash itself when it is a 64-char hex string), h2 is forced odd, and bit
+// i is (h1 + i*h2) mod m in wrapping uint64 arithmetic, least significant
+// bit of each byte first. The testvectors package pins this scheme to an
+// independent implementation; the server has no published spec for it, so
+// a snapshot built differently would reject hashes it holds.
+//
+// A zero BloomFilter is empty: MayExist returns false and Add does nothing.
+// Use NewBloomFilter or decode a snapshot to get a usable filter.
+type BloomFilter struct {
+	mu          sync.RWMutex
+	bits        []byte
+	m           uint64
+	k           int
+	count       int
+	generatedAt time.Time
+}
+
+// NewBloomFilter sizes a filter for expected hashes at the given false
+// positive rate (default 0.01 when out of range).
+func NewBloomFilter(expected int, fpRate float64) *BloomFilter {
+	if expected < 1 {
+		expected = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(expected) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = (m + 7) / 8 * 8
+	k := int(math.Round(float64(m) / float64(expected) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{bits: make([]byte, m/8), m: m, k: k}
+}
+
+// bloomPositions returns h1 and h2 for a receipt hash.
+func bloomPositions(hash string) (uint64, uint64) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	digest, err := hex.DecodeString(hash)
+	if err != nil || len(digest) != sha256.Size {
+		sum := sha256.Sum256([]byte(hash))
+		digest = sum[:]
+	}
+	h1 := binary.BigEndian.Uint64(digest[0:8])
+	h2 := binary.BigEndian.Uint64(digest[8:16]) | 1 // odd, so probes never repeat
+	return h1, h2
+}
+
+// Add inserts a receipt hash.
+func (b *BloomFilter) Add(hash string) {
+	h1, h2 := bloomPositions(hash)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.m == 0 {
+		return
+	}
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		b.bits[bit/8] |= 1 << (bit % 8)
+	}
+	b.count++
+}
+
+// MayExist reports whether hash may have been added. False means the hash
+// was definitely not added; true may be a false positive.
+func (b *BloomFilter) MayExist(hash string) bool {
+	h1, h2 := bloomPositions(hash)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.m == 0 {
+		return false
+	}
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		if b.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of hashes added (as reported by the server for
+// downloaded snapshots).
+func (b *BloomFilter) Count() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.count
+}
+
+// GeneratedAt returns when the snapshot was built; receipts issued after
+// it are not covered.
+func (b *BloomFilter) GeneratedAt() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.generatedAt
+}
+
+// FalsePositiveRate estimates the current false positive probability.
+func (b *BloomFilter) FalsePositiveRate() float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.m == 0 {
+		return 0
+	}
+	return math.Pow(1-math.Exp(-float64(b.k)*float64(b.count)/float64(b.m)), float64(b.k))
+}
+
+// bloomSnapshot is the wire form of a BloomFilter.
+type bloomSnapshot struct {
+	Bits        string    `json:"bits"`
+	M           uint64    `json:"m"`
+	K           int       `json:"k"`
+	Count       int       `json:"count"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// MarshalJSON encodes the filter in the server snapshot format, so built
+// filters can be cached to disk and reloaded.
+func (b *BloomFilter) MarshalJSON() ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return json.Marshal(bloomSnapshot{
+		Bits:        base64.StdEncoding.EncodeToString(b.bits),
+		M:           b.m,
+		K:           b.k,
+		Count:       b.count,
+		GeneratedAt: b.generatedAt,
+	})
+}
+
+// UnmarshalJSON decodes a filter in the server snapshot format.
+func (b *BloomFilter) UnmarshalJSON(data []byte) error {
+	var snap bloomSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	bits, err := base64.StdEncoding.DecodeString(snap.Bits)
+	if err != nil {
+		return fmt.Errorf("invalid bloom filter bits: %w", err)
+	}
+	if snap.M == 0 || snap.K < 1 || uint64(len(bits))*8 < snap.M {
+		return fmt.Errorf("invalid bloom filter: m=%d k=%d with %d bytes", snap.M, snap.K, len(bits))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bits, b.m, b.k, b.count, b.generatedAt = bits, snap.M, snap.K, snap.Count, snap.GeneratedAt
+	return nil
+}
+
+// BloomSnapshot downloads the server's published bloom filter of this
+// account's receipt hashes.
+func (c *Client) BloomSnapshot() (*BloomFilter, error) {
+	respBody, err := c.doRequest("GET", "/bloom", nil)
+	if err != nil {
+		return nil, err
+	}
+	var filter BloomFilter
+	if err := json.Unmarshal(respBody, &filter); err != nil {
+		return nil, &NotaryError{Message: fmt.Sprintf("failed to parse bloom filter: %v", err), Code: "ERR_PARSE"}
+	}
+	return &filter, nil
+}
+
+// BuildBloomFilter builds a filter from every receipt in a local Store
+// (for example one kept current by a Syncer).
+func BuildBloomFilter(store Store, fpRate float64) (*BloomFilter, error) {
+	receipts, err := store.Query(LocalQuery{})
+	if err != nil {
+		return nil, err
+	}
+	filter := NewBloomFilter(len(receipts), fpRate)
+	for _, r := range receipts {
+		filter.Add(r.ReceiptHash)
+	}
+	filter.generatedAt = time.Now().UTC()
+	return filter, nil
+}
@@ -0,0 +1,73 @@
+package notary
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// TestBloomVectors checks the probe positions against the filters in
+// testvectors/bloom.json, which generate.py computes independently: every
+// position of an added hash is set in the expected bits, and adding the
+// hashes to an empty filter of the same shape reproduces them exactly.
+func TestBloomVectors(t *testing.T) {
+	data, err := os.ReadFile("../testvectors/bloom.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set struct {
+		Filters []struct {
+			Name   string   `json:"name"`
+			M      uint64   `json:"m"`
+			K      int      `json:"k"`
+			Added  []string `json:"added"`
+			Bits   string   `json:"bits"`
+			Absent []string `json:"absent"`
+		} `json:"filters"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Filters) == 0 {
+		t.Fatal("bloom.json holds no filters")
+	}
+
+	for _, v := range set.Filters {
+		want, err := base64.StdEncoding.DecodeString(v.Bits)
+		if err != nil || uint64(len(want)) != (v.M+7)/8 {
+			t.Fatalf("%s: bits decode to %d bytes (%v), want %d", v.Name, len(want), err, (v.M+7)/8)
+		}
+		for _, h := range v.Added {
+			h1, h2 := bloomPositions(h)
+			for i := 0; i < v.K; i++ {
+				bit := (h1 + uint64(i)*h2) % v.M
+				if want[bit/8]&(1<<(bit%8)) == 0 {
+					t.Errorf("%s: probe %d of %q lands on bit %d, which the vector leaves clear", v.Name, i, h, bit)
+				}
+			}
+		}
+
+		filter := &BloomFilter{bits: make([]byte, len(want)), m: v.M, k: v.K}
+		for _, h := range v.Added {
+			filter.Add(h)
+		}
+		if !bytes.Equal(filter.bits, want) {
+			t.Errorf("%s: bits = %s, want %s", v.Name, base64.StdEncoding.EncodeToString(filter.bits), v.Bits)
+		}
+		for _, h := range v.Absent {
+			if filter.MayExist(h) {
+				t.Errorf("%s: accepts absent hash %s", v.Name, h)
+			}
+		}
+	}
+}
+
+func TestBloomFilterZeroValue(t *testing.T) {
+	var filter BloomFilter
+	filter.Add("sha256:abc")
+	if filter.MayExist("sha256:abc") || filter.Count() != 0 {
+		t.Error("zero BloomFilter is not empty")
+	}
+}
@@ -0,0 +1,84 @@
+package notary
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/json"
+	"strings"
+)
+
+// Canonical format versions. v1 signs the pipe-joined field string; v2 signs
+// the canonical JSON of the receipt with Ed25519ph (SHA-512 pre-hash).
+const (
+	CanonicalV1 = 1
+	CanonicalV2 = 2
+)
+
+// AlgEd25519ph is the alg value of receipts signed over canonical format v2.
+const AlgEd25519ph = "Ed25519ph"
+
+// detachedFields are carried alongside a v2 receipt but not signed.
+var detachedFields = map[string]bool{
+	"signature":    true,
+	"receipt_hash": true,
+	"verify_url":   true,
+}
+
+// CanonicalVersion returns the canonical format a receipt was signed over.
+// An explicit alg decides: Ed25519ph receipts use v2, any other alg v1.
+// Without alg, receipts with schema_version 2.x or later use v2 and the rest
+// (including receipts without schema_version) v1.
+func CanonicalVersion(receipt map[string]any) int {
+	if alg := getString(receipt, "alg"); alg != "" {
+		if strings.EqualFold(alg, AlgEd25519ph) {
+			return CanonicalV2
+		}
+		return CanonicalV1
+	}
+	if version := getString(receipt, "schema_version"); version != "" {
+		if major, err := schemaMajor(version); err == nil && major >= 2 {
+			return CanonicalV2
+		}
+	}
+	return CanonicalV1
+}
+
+// CanonicalJSON encodes v as compact JSON with sorted object keys and no
+// HTML escaping, matching the server's canonical serialization.
+func CanonicalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// encoding/json sorts map keys, so maps encode deterministically.
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// buildCanonicalV2 returns the canonical JSON of every signed field, i.e.
+// the receipt minus its detached signature envelope.
+func buildCanonicalV2(receipt map[string]any) string {
+	signed := make(map[string]any, len(receipt))
+	for k, v := range receipt {
+		if !detachedFields[k] {
+			signed[k] = v
+		}
+	}
+	data, err := CanonicalJSON(signed)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// verifyCanonical checks sig over message using the scheme for version.
+func verifyCanonical(version int, pub ed25519.PublicKey, message, sig []byte) bool {
+	if version == CanonicalV2 {
+		digest := sha512.Sum512(message)
+		return ed25519.VerifyWithOptions(pub, digest[:], sig, &ed25519.Options{Hash: crypto.SHA512}) == nil
+	}
+	return ed25519.Verify(pub, message, sig)
+}
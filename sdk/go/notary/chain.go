@@ -0,0 +1,168 @@
+package notary
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ChainLink is one receipt in a reconstructed chain.
+type ChainLink struct {
+	Sequence            int    `json:"sequence"`
+	ReceiptHash         string `json:"receipt_hash"`
+	PreviousReceiptHash string `json:"previous_receipt_hash,omitempty"`
+	ActionType          string `json:"action_type,omitempty"`
+	Timestamp           string `json:"timestamp,omitempty"`
+	// LinkOK is false when PreviousReceiptHash does not match the receipt
+	// at Sequence-1 (only checked when that receipt is present).
+	LinkOK bool `json:"link_ok"`
+}
+
+// ChainGap is a run of missing sequence numbers, inclusive.
+type ChainGap struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// BeforeHash and AfterHash are the receipts on either side of the gap.
+	BeforeHash string `json:"before_hash"`
+	AfterHash  string `json:"after_hash"`
+}
+
+// Sequences returns every missing sequence number in the gap.
+func (g ChainGap) Sequences() []int {
+	seqs := make([]int, 0, g.End-g.Start+1)
+	for s := g.Start; s <= g.End; s++ {
+		seqs = append(seqs, s)
+	}
+	return seqs
+}
+
+// ChainReport is the result of ReconstructChain, suitable for audit tooling.
+type ChainReport struct {
+	AgentID string      `json:"agent_id"`
+	From    time.Time   `json:"from"`
+	To      time.Time   `json:"to"`
+	Links   []ChainLink `json:"links"`
+	Gaps    []ChainGap  `json:"gaps"`
+	// BrokenLinks are sequences whose previous hash does not match.
+	BrokenLinks []int `json:"broken_links"`
+	// Duplicates are sequences that appeared more than once.
+	Duplicates []int `json:"duplicates"`
+	// Unsequenced counts history items without a chain_sequence.
+	Unsequenced int `json:"unsequenced"`
+	// Complete is true when there are no gaps, broken links, or duplicates.
+	Complete bool `json:"complete"`
+}
+
+// ReconstructChain pulls an agent's history between from and to, orders it
+// by chain_sequence, rebuilds the hash links, and reports gaps. The server
+// filters the history by agent; an empty agentID takes every receipt.
+//
+//	report, err := client.ReconstructChain("billing-agent", start, end)
+//	for _, gap := range report.Gaps {
+//	    fmt.Printf("missing %d-%d between %s and %s\n", gap.Start, gap.End, gap.BeforeHash, gap.AfterHash)
+//	}
+func (c *Client) ReconstructChain(agentID string, from, to time.Time) (*ChainReport, error) {
+	if !to.IsZero() && to.Before(from) {
+		return nil, &NotaryError{Message: "chain range end is before start", Code: ErrValidationFailed}
+	}
+
+	opts := HistoryOptions{Page: 1, PageSize: 100, AgentID: agentID}
+	if !from.IsZero() {
+		opts.StartDate = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		opts.EndDate = to.UTC().Format(time.RFC3339)
+	}
+
+	var items []map[string]any
+	for {
+		page, err := c.History(opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		if opts.Page >= page.TotalPages || len(page.Items) == 0 {
+			break
+		}
+		opts.Page++
+	}
+
+	return buildChainReport(agentID, from, to, items), nil
+}
+
+func buildChainReport(agentID string, from, to time.Time, items []map[string]any) *ChainReport {
+	report := &ChainReport{AgentID: agentID, From: from, To: to}
+
+	bySeq := make(map[int]ChainLink)
+	for _, item := range items {
+		// History items carry the receipt under "receipt".
+		r := storedReceiptFromItem(item)
+		if !r.Timestamp.IsZero() {
+			if (!from.IsZero() && r.Timestamp.Before(from)) || (!to.IsZero() && r.Timestamp.After(to)) {
+				continue
+			}
+		}
+
+		seq, ok := r.Receipt["chain_sequence"].(float64)
+		if !ok {
+			report.Unsequenced++
+			continue
+		}
+		link := ChainLink{
+			Sequence:            int(seq),
+			ReceiptHash:         r.ReceiptHash,
+			PreviousReceiptHash: getString(r.Receipt, "previous_receipt_hash"),
+			ActionType:          r.ActionType,
+			Timestamp:           getString(r.Receipt, "timestamp"),
+			LinkOK:              true,
+		}
+		if existing, dup := bySeq[link.Sequence]; dup {
+			if existing.ReceiptHash != link.ReceiptHash {
+				report.Duplicates = append(report.Duplicates, link.Sequence)
+			}
+			continue
+		}
+		bySeq[link.Sequence] = link
+	}
+
+	seqs := make([]int, 0, len(bySeq))
+	for s := range bySeq {
+		seqs = append(seqs, s)
+	}
+	sort.Ints(seqs)
+	sort.Ints(report.Duplicates)
+
+	for i, s := range seqs {
+		link := bySeq[s]
+		if i > 0 {
+			prev := bySeq[seqs[i-1]]
+			if s == prev.Sequence+1 {
+				if link.PreviousReceiptHash != prev.ReceiptHash {
+					link.LinkOK = false
+					report.BrokenLinks = append(report.BrokenLinks, s)
+				}
+			} else {
+				report.Gaps = append(report.Gaps, ChainGap{
+					Start:      prev.Sequence + 1,
+					End:        s - 1,
+					BeforeHash: prev.ReceiptHash,
+					AfterHash:  link.ReceiptHash,
+				})
+			}
+		}
+		report.Links = append(report.Links, link)
+	}
+
+	report.Complete = len(report.Gaps) == 0 && len(report.BrokenLinks) == 0 && len(report.Duplicates) == 0
+	return report
+}
+
+// String summarizes the report in one line.
+func (r *ChainReport) String() string {
+	missing := 0
+	for _, g := range r.Gaps {
+		missing += g.End - g.Start + 1
+	}
+	return fmt.Sprintf("chain %s: %d receipts, %d gaps (%d missing), %d broken links, %d duplicates",
+		r.AgentID, len(r.Links), len(r.Gaps), missing, len(r.BrokenLinks), len(r.Duplicates))
+}
@@ -0,0 +1,87 @@
+package notary_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hellothere012/notaryos-go/notary"
+	"github.com/hellothere012/notaryos-go/notarytest"
+)
+
+func chainFixture(t *testing.T) (*notary.Client, []string) {
+	t.Helper()
+	srv := notarytest.NewMockServer(&notarytest.MockConfig{AgentID: "billing-agent"})
+	t.Cleanup(srv.Close)
+	client, err := notary.NewClient("notary_test_key", &notary.Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes []string
+	for i := 0; i < 5; i++ {
+		receipt, err := client.Issue("billing.charge", map[string]any{"n": i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, receipt.ReceiptHash)
+	}
+	return client, hashes
+}
+
+func TestReconstructChain(t *testing.T) {
+	client, hashes := chainFixture(t)
+
+	report, err := client.ReconstructChain("billing-agent", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Complete || len(report.Links) != 5 || report.Unsequenced != 0 {
+		t.Fatalf("report = %s, unsequenced %d", report, report.Unsequenced)
+	}
+	for i, link := range report.Links {
+		if link.Sequence != i+1 || link.ReceiptHash != hashes[i] || !link.LinkOK {
+			t.Errorf("link %d = %+v", i, link)
+		}
+		if i > 0 && link.PreviousReceiptHash != hashes[i-1] {
+			t.Errorf("link %d previous = %s, want %s", i, link.PreviousReceiptHash, hashes[i-1])
+		}
+	}
+
+	// Removing receipt 3 on the server leaves a gap between 2 and 4.
+	if _, err := client.MarkForDeletion([]string{hashes[2]}, hashes[4]); err != nil {
+		t.Fatal(err)
+	}
+	report, err = client.ReconstructChain("billing-agent", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []notary.ChainGap{{Start: 3, End: 3, BeforeHash: hashes[1], AfterHash: hashes[3]}}
+	if report.Complete || !reflect.DeepEqual(report.Gaps, want) {
+		t.Errorf("gaps = %+v, want %+v", report.Gaps, want)
+	}
+}
+
+func TestReconstructChainBrokenLink(t *testing.T) {
+	client, hashes := chainFixture(t)
+	if _, err := client.Issue("billing.charge", map[string]any{"n": 5}, notary.WithPreviousHash(hashes[0])); err != nil {
+		t.Fatal(err)
+	}
+	report, err := client.ReconstructChain("billing-agent", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Complete || !reflect.DeepEqual(report.BrokenLinks, []int{6}) {
+		t.Errorf("broken links = %v, want [6]", report.BrokenLinks)
+	}
+}
+
+func TestReconstructChainFiltersByAgent(t *testing.T) {
+	client, _ := chainFixture(t)
+	report, err := client.ReconstructChain("other-agent", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Links) != 0 || report.Unsequenced != 0 {
+		t.Errorf("other-agent chain = %s, want no receipts", report)
+	}
+}
@@ -11,15 +11,14 @@ package notary
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
-	"sort"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -62,26 +61,49 @@ type Config struct {
 	BaseURL    string
 	Timeout    time.Duration
 	MaxRetries int
+	// AppName and AppVersion are appended to the User-Agent and sent in
+	// X-Notary-Client-Meta so receipts can be attributed per service.
+	AppName    string
+	AppVersion string
+	// ClientMeta adds key/value pairs to X-Notary-Client-Meta.
+	ClientMeta map[string]string
+	// Headers are sent on every request (they cannot override auth headers).
+	Headers map[string]string
+	// Transport tunes connection pooling, HTTP/2, health pings and the
+	// circuit breaker.
+	Transport TransportConfig
+	// Hooks are lifecycle callbacks invoked for every Issue and Verify.
+	Hooks Hooks
+	// ActionRegistry restricts the action types Issue and RecordAction accept.
+	ActionRegistry *ActionRegistry
+	// Logger receives SDK warnings (default: the standard logger).
+	Logger Logger
+	// TimeSync corrects the timestamps the SDK generates, and its
+	// time-based receipt checks, by the server/client clock offset
+	// measured from response Date headers (see ClockSkew and SyncTime).
+	TimeSync bool
 }
 
 // Receipt represents a signed Notary receipt.
 type Receipt struct {
-	ReceiptID           string         `json:"receipt_id"`
-	Timestamp           string         `json:"timestamp"`
-	AgentID             string         `json:"agent_id"`
-	ActionType          string         `json:"action_type"`
-	PayloadHash         string         `json:"payload_hash"`
-	Signature           string         `json:"signature"`
-	SignatureType       string         `json:"signature_type"`
-	KeyID               string         `json:"key_id"`
-	KID                 string         `json:"kid,omitempty"`
-	Alg                 string         `json:"alg,omitempty"`
-	SchemaVersion       string         `json:"schema_version,omitempty"`
-	ChainSequence       *int           `json:"chain_sequence,omitempty"`
-	PreviousReceiptHash *string        `json:"previous_receipt_hash,omitempty"`
-	ReceiptHash         string         `json:"receipt_hash,omitempty"`
-	VerifyURL           string         `json:"verify_url,omitempty"`
-	Raw                 map[string]any `json:"-"`
+	ReceiptID           string            `json:"receipt_id"`
+	Timestamp           string            `json:"timestamp"`
+	AgentID             string            `json:"agent_id"`
+	ActionType          string            `json:"action_type"`
+	PayloadHash         string            `json:"payload_hash"`
+	Signature           string            `json:"signature"`
+	SignatureType       string            `json:"signature_type"`
+	KeyID               string            `json:"key_id"`
+	KID                 string            `json:"kid,omitempty"`
+	Alg                 string            `json:"alg,omitempty"`
+	SchemaVersion       string            `json:"schema_version,omitempty"`
+	ChainSequence       *int              `json:"chain_sequence,omitempty"`
+	PreviousReceiptHash *string           `json:"previous_receipt_hash,omitempty"`
+	ReceiptHash         string            `json:"receipt_hash,omitempty"`
+	VerifyURL           string            `json:"verify_url,omitempty"`
+	Tags                []string          `json:"tags,omitempty"`
+	IndexedFields       map[string]string `json:"indexed_fields,omitempty"`
+	Raw                 map[string]any    `json:"-"`
 }
 
 // VerificationResult holds the result of receipt verification.
@@ -93,6 +115,11 @@ type VerificationResult struct {
 	Reason      string         `json:"reason"`
 	Details     map[string]any `json:"details"`
 	FromCache   bool           `json:"from_cache,omitempty"`
+	// PolicyOK and Violations are set when a PolicyEngine is attached.
+	PolicyOK   *bool             `json:"policy_ok,omitempty"`
+	Violations []PolicyViolation `json:"policy_violations,omitempty"`
+	// PayloadOK is set by VerifyWithPayload.
+	PayloadOK *bool `json:"payload_ok,omitempty"`
 }
 
 // ServiceStatus holds the Notary service health info.
@@ -128,19 +155,47 @@ type NotaryError struct {
 	Code    string
 	Status  int
 	Details map[string]any
+	// Meta holds response headers (request id, rate limits) when the error
+	// came from an HTTP response; nil for client-side failures.
+	Meta *ResponseMeta
 }
 
 func (e *NotaryError) Error() string {
+	var msg string
 	if e.Code != "" {
-		return fmt.Sprintf("NotaryError [%s] (HTTP %d): %s", e.Code, e.Status, e.Message)
+		msg = fmt.Sprintf("NotaryError [%s] (HTTP %d): %s", e.Code, e.Status, e.Message)
+	} else {
+		msg = fmt.Sprintf("NotaryError (HTTP %d): %s", e.Status, e.Message)
+	}
+	if e.Meta != nil && e.Meta.RequestID != "" {
+		msg += " (request_id=" + e.Meta.RequestID + ")"
 	}
-	return fmt.Sprintf("NotaryError (HTTP %d): %s", e.Status, e.Message)
+	return msg
+}
+
+// RequestID returns the server request id for support tickets, or "" if unknown.
+func (e *NotaryError) RequestID() string {
+	if e.Meta == nil {
+		return ""
+	}
+	return e.Meta.RequestID
 }
 
 // IssueOptions holds optional parameters for issuing receipts.
+//
+// Deprecated: pass IssueOptions such as WithPreviousHash and WithMetadata
+// instead. IssueOptions still implements IssueOption, so existing calls
+// compile.
 type IssueOptions struct {
 	PreviousReceiptHash string
 	Metadata            map[string]any
+	// HashAlgorithm asks the server to hash the payload with this algorithm
+	// (default SHA-256); the receipt's payload_hash carries its prefix.
+	HashAlgorithm HashAlgorithm
+	// Tags and IndexedFields are indexed by the server, so History and Find
+	// can filter on them ("env:prod"; {"tenant": "acme", "ticket": "OPS-42"}).
+	Tags          []string
+	IndexedFields map[string]string
 }
 
 // Client is the NotaryOS API client.
@@ -149,6 +204,20 @@ type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	maxRetries int
+	userAgent  string
+	clientMeta string
+	headers    map[string]string
+	breaker    *circuitBreaker
+	stopHealth chan struct{}
+	healthDone chan struct{}
+	closeOnce  sync.Once
+	hooks      Hooks
+	ledger     *Ledger
+	policy     *PolicyEngine
+	registry   *ActionRegistry
+	logger     Logger
+	clock      *serverClock
+	timeSync   bool
 }
 
 // NewClient creates a new Notary client.
@@ -166,6 +235,16 @@ func NewClient(apiKey string, config *Config) (*Client, error) {
 	baseURL := DefaultBaseURL
 	timeout := DefaultTimeout
 	maxRetries := DefaultMaxRetries
+	userAgent := "notary-go-sdk/" + SDKVersion
+	meta := url.Values{}
+	meta.Set("sdk", "go")
+	meta.Set("sdk_version", SDKVersion)
+	headers := map[string]string{}
+	var transport TransportConfig
+	var hooks Hooks
+	var registry *ActionRegistry
+	var logger Logger
+	var timeSync bool
 
 	if config != nil {
 		if config.BaseURL != "" {
@@ -177,68 +256,140 @@ func NewClient(apiKey string, config *Config) (*Client, error) {
 		if config.MaxRetries >= 0 {
 			maxRetries = config.MaxRetries
 		}
+		if config.AppName != "" {
+			app := config.AppName
+			meta.Set("app", config.AppName)
+			if config.AppVersion != "" {
+				app += "/" + config.AppVersion
+				meta.Set("app_version", config.AppVersion)
+			}
+			userAgent += " " + app
+		}
+		for k, v := range config.ClientMeta {
+			meta.Set(k, v)
+		}
+		for k, v := range config.Headers {
+			headers[http.CanonicalHeaderKey(k)] = v
+		}
+		transport = config.Transport
+		hooks = config.Hooks
+		registry = config.ActionRegistry
+		logger = config.Logger
+		timeSync = config.TimeSync
 	}
+	clock := &serverClock{}
 
-	return &Client{
+	c := &Client{
 		apiKey:  apiKey,
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: timeout,
+			Timeout:   timeout,
+			Transport: &clockTransport{next: newTransport(transport), clock: clock},
 		},
 		maxRetries: maxRetries,
-	}, nil
+		userAgent:  userAgent,
+		clientMeta: meta.Encode(),
+		headers:    headers,
+		breaker:    newCircuitBreaker(transport.BreakerThreshold, transport.BreakerCooldown),
+		hooks:      hooks,
+		registry:   registry,
+		logger:     logger,
+		clock:      clock,
+		timeSync:   timeSync,
+	}
+	if transport.HealthCheckInterval > 0 {
+		c.startHealthCheck(transport.HealthCheckInterval)
+	}
+	return c, nil
+}
+
+// setHeaders applies the default headers shared by every request.
+// Authentication headers are set by the caller afterwards.
+func (c *Client) setHeaders(req *http.Request) {
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("X-Notary-Client-Meta", c.clientMeta)
 }
 
 func (c *Client) doRequest(method, path string, body any) ([]byte, error) {
+	respBody, _, err := c.doRequestMeta(method, path, body)
+	return respBody, err
+}
+
+func (c *Client) doRequestMeta(method, path string, body any) ([]byte, *ResponseMeta, error) {
+	return c.doCall(method, path, body, nil)
+}
+
+// doCall performs an authenticated request with retries, applying the
+// per-call options when co is non-nil.
+func (c *Client) doCall(method, path string, body any, co *callOptions) ([]byte, *ResponseMeta, error) {
 	url := c.baseURL + "/v1/notary" + path
+	ctx, cancel := co.context()
+	defer cancel()
 
 	var bodyReader io.Reader
 	if body != nil {
 		data, err := json.Marshal(body)
 		if err != nil {
-			return nil, &NotaryError{Message: "failed to marshal request body", Code: "ERR_MARSHAL"}
+			return nil, nil, &NotaryError{Message: "failed to marshal request body", Code: "ERR_MARSHAL"}
 		}
 		bodyReader = bytes.NewReader(data)
 	}
 
 	var lastErr error
+	var lastMeta *ResponseMeta
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, lastMeta, contextError(ctx, err, lastMeta)
+		}
+		if !c.breaker.allow() {
+			return nil, lastMeta, &NotaryError{Message: "circuit breaker open: service is failing, not sending request", Code: "ERR_CIRCUIT_OPEN", Meta: lastMeta}
+		}
+
 		// Reset body reader for retries
 		if body != nil {
 			data, _ := json.Marshal(body)
 			bodyReader = bytes.NewReader(data)
 		}
 
-		req, err := http.NewRequest(method, url, bodyReader)
+		req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 		if err != nil {
-			return nil, &NotaryError{Message: fmt.Sprintf("failed to create request: %v", err), Code: "ERR_REQUEST"}
+			return nil, nil, &NotaryError{Message: fmt.Sprintf("failed to create request: %v", err), Code: "ERR_REQUEST"}
 		}
 
+		c.setHeaders(req)
+		co.setHeaders(req)
 		req.Header.Set("X-API-Key", c.apiKey)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("User-Agent", "notary-go-sdk/"+SDKVersion)
 
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
+			c.breaker.failure()
 			if attempt < c.maxRetries {
-				time.Sleep(time.Duration(math.Pow(2, float64(attempt))) * time.Second)
+				sleepContext(ctx, time.Duration(math.Pow(2, float64(attempt)))*time.Second)
 				lastErr = err
 				continue
 			}
-			return nil, &NotaryError{
-				Message: fmt.Sprintf("connection failed: %v", err),
-				Code:    "ERR_CONNECTION",
-			}
+			return nil, nil, connectionError(ctx, err)
 		}
 
+		meta := newResponseMeta(resp)
+		lastMeta = meta
+		if resp.StatusCode >= 500 {
+			c.breaker.failure()
+		} else {
+			c.breaker.success()
+		}
 		respBody, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		if err != nil {
-			return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ"}
+			return nil, meta, &NotaryError{Message: "failed to read response", Code: "ERR_READ", Meta: meta}
 		}
 
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			return respBody, nil
+			return respBody, meta, nil
 		}
 
 		// Parse error
@@ -258,53 +409,104 @@ func (c *Client) doRequest(method, path string, body any) ([]byte, error) {
 
 		switch {
 		case resp.StatusCode == 401:
-			return nil, &NotaryError{Message: errMsg, Code: errCode, Status: 401}
+			return nil, meta, &NotaryError{Message: errMsg, Code: errCode, Status: 401, Meta: meta}
 		case resp.StatusCode == 429:
 			if attempt < c.maxRetries {
-				time.Sleep(5 * time.Second)
+				// Honor the server's Retry-After (0 retries at once);
+				// 5s when it sends none.
+				wait := 5 * time.Second
+				if resp.Header.Get("Retry-After") != "" {
+					wait = meta.RetryAfter
+				}
+				sleepContext(ctx, wait)
 				continue
 			}
-			return nil, &NotaryError{Message: errMsg, Code: "ERR_RATE_LIMIT_EXCEEDED", Status: 429}
+			return nil, meta, &NotaryError{Message: errMsg, Code: "ERR_RATE_LIMIT_EXCEEDED", Status: 429, Meta: meta}
 		case resp.StatusCode == 422:
-			return nil, &NotaryError{Message: errMsg, Code: errCode, Status: 422, Details: errResp.Error.Details}
+			return nil, meta, &NotaryError{Message: errMsg, Code: errCode, Status: 422, Details: errResp.Error.Details, Meta: meta}
 		case resp.StatusCode >= 500:
 			if attempt < c.maxRetries {
-				time.Sleep(time.Duration(math.Pow(2, float64(attempt))) * time.Second)
-				lastErr = &NotaryError{Message: errMsg, Code: errCode, Status: resp.StatusCode}
+				sleepContext(ctx, time.Duration(math.Pow(2, float64(attempt)))*time.Second)
+				lastErr = &NotaryError{Message: errMsg, Code: errCode, Status: resp.StatusCode, Meta: meta}
 				continue
 			}
-			return nil, &NotaryError{Message: errMsg, Code: errCode, Status: resp.StatusCode}
+			return nil, meta, &NotaryError{Message: errMsg, Code: errCode, Status: resp.StatusCode, Meta: meta}
 		default:
-			return nil, &NotaryError{Message: errMsg, Code: errCode, Status: resp.StatusCode, Details: errResp.Error.Details}
+			return nil, meta, &NotaryError{Message: errMsg, Code: errCode, Status: resp.StatusCode, Details: errResp.Error.Details, Meta: meta}
 		}
 	}
 
 	if lastErr != nil {
-		return nil, &NotaryError{Message: fmt.Sprintf("max retries exceeded: %v", lastErr), Code: "ERR_MAX_RETRIES"}
+		return nil, lastMeta, &NotaryError{Message: fmt.Sprintf("max retries exceeded: %v", lastErr), Code: "ERR_MAX_RETRIES", Meta: lastMeta}
 	}
-	return nil, &NotaryError{Message: "request failed", Code: "ERR_UNKNOWN"}
+	return nil, lastMeta, &NotaryError{Message: "request failed", Code: "ERR_UNKNOWN", Meta: lastMeta}
 }
 
 // Issue creates a signed receipt for an action.
 //
 //	receipt, err := client.Issue("my_action", map[string]any{"key": "value"})
-func (c *Client) Issue(actionType string, payload map[string]any, opts ...IssueOptions) (*Receipt, error) {
+//	receipt, err := client.Issue("my_action", payload, notary.WithPreviousHash(prev))
+func (c *Client) Issue(actionType string, payload map[string]any, opts ...IssueOption) (*Receipt, error) {
+	receipt, _, err := c.IssueWithMeta(actionType, payload, opts...)
+	return receipt, err
+}
+
+// IssueWithMeta is Issue that also returns the response metadata
+// (request id, rate-limit headers, server timing).
+func (c *Client) IssueWithMeta(actionType string, payload map[string]any, opts ...IssueOption) (*Receipt, *ResponseMeta, error) {
+	co := newCallOptions(opts, IssueOption.applyIssue)
+	receipt, meta, err := c.issue(actionType, payload, co)
+	if receipt != nil {
+		// Also covers ERR_LEDGER, where the receipt exists but wasn't recorded locally.
+		c.fireIssued(receipt)
+	} else if err != nil {
+		c.fireIssueFailed(err, actionType, payload, co)
+	}
+	return receipt, meta, err
+}
+
+func (c *Client) issue(actionType string, payload map[string]any, co *callOptions) (*Receipt, *ResponseMeta, error) {
+	if err := c.checkAction(actionType); err != nil {
+		return nil, nil, err
+	}
+
 	body := map[string]any{
 		"action_type": actionType,
 		"payload":     payload,
 	}
-	if len(opts) > 0 {
-		if opts[0].PreviousReceiptHash != "" {
-			body["previous_receipt_hash"] = opts[0].PreviousReceiptHash
+	opts := co.issue
+	if opts.PreviousReceiptHash != "" {
+		body["previous_receipt_hash"] = opts.PreviousReceiptHash
+	}
+	if opts.Metadata != nil {
+		body["metadata"] = opts.Metadata
+	}
+	if alg := opts.HashAlgorithm; alg != "" {
+		if _, err := hashBytes(alg, nil); err != nil {
+			return nil, nil, &NotaryError{Message: err.Error(), Code: "ERR_UNSUPPORTED_HASH"}
 		}
-		if opts[0].Metadata != nil {
-			body["metadata"] = opts[0].Metadata
+		body["hash_algorithm"] = string(alg)
+	}
+	for _, tag := range opts.Tags {
+		if tag == "" {
+			return nil, nil, &NotaryError{Message: "tags must not be empty", Code: ErrValidationFailed}
 		}
 	}
+	if len(opts.Tags) > 0 {
+		body["tags"] = opts.Tags
+	}
+	for name := range opts.IndexedFields {
+		if name == "" {
+			return nil, nil, &NotaryError{Message: "indexed field names must not be empty", Code: ErrValidationFailed}
+		}
+	}
+	if len(opts.IndexedFields) > 0 {
+		body["indexed_fields"] = opts.IndexedFields
+	}
 
-	respBody, err := c.doRequest("POST", "/issue", body)
+	respBody, meta, err := c.doCall("POST", "/issue", body, co)
 	if err != nil {
-		return nil, err
+		return nil, meta, err
 	}
 
 	var resp struct {
@@ -314,14 +516,14 @@ func (c *Client) Issue(actionType string, payload map[string]any, opts ...IssueO
 		ChainPosition *int           `json:"chain_position"`
 	}
 	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, &NotaryError{Message: "failed to parse response", Code: "ERR_PARSE"}
+		return nil, meta, &NotaryError{Message: "failed to parse response", Code: "ERR_PARSE", Meta: meta}
 	}
 
 	// Re-marshal the receipt portion and unmarshal into Receipt struct
 	receiptBytes, _ := json.Marshal(resp.Receipt)
 	var receipt Receipt
 	if err := json.Unmarshal(receiptBytes, &receipt); err != nil {
-		return nil, &NotaryError{Message: "failed to parse receipt", Code: "ERR_PARSE"}
+		return nil, meta, &NotaryError{Message: "failed to parse receipt", Code: "ERR_PARSE", Meta: meta}
 	}
 
 	receipt.ReceiptHash = resp.ReceiptHash
@@ -329,31 +531,100 @@ func (c *Client) Issue(actionType string, payload map[string]any, opts ...IssueO
 	receipt.ChainSequence = resp.ChainPosition
 	receipt.Raw = resp.Receipt
 
-	return &receipt, nil
+	if c.ledger != nil {
+		if err := c.ledger.Append(&receipt); err != nil {
+			// The receipt was issued; return it so the caller can retry the append.
+			return &receipt, meta, &NotaryError{Message: fmt.Sprintf("receipt issued but not recorded in ledger: %v", err), Code: "ERR_LEDGER", Meta: meta}
+		}
+	}
+
+	return &receipt, meta, nil
+}
+
+// SetPolicy runs a PolicyEngine on every Verify, reporting compliance in
+// VerificationResult.PolicyOK and Violations alongside the cryptographic
+// checks. Valid is not affected. Pass nil to detach.
+func (c *Client) SetPolicy(policy *PolicyEngine) {
+	c.policy = policy
+}
+
+// AttachLedger makes every successful Issue append the receipt to a local
+// ledger. If the append fails, Issue returns both the receipt and an
+// ERR_LEDGER error. The ledger stamps entries with the client clock from
+// then on. Pass nil to detach.
+func (c *Client) AttachLedger(ledger *Ledger) {
+	if ledger != nil {
+		ledger.mu.Lock()
+		ledger.now = c.Now
+		ledger.mu.Unlock()
+	}
+	c.ledger = ledger
 }
 
 // Verify checks a receipt's signature and integrity.
 //
 //	result, err := client.Verify(receipt)
 //	fmt.Println(result.Valid)
-func (c *Client) Verify(receipt *Receipt) (*VerificationResult, error) {
+func (c *Client) Verify(receipt *Receipt, opts ...VerifyOption) (*VerificationResult, error) {
+	result, _, err := c.VerifyWithMeta(receipt, opts...)
+	return result, err
+}
+
+// VerifyWithMeta is Verify that also returns the response metadata.
+func (c *Client) VerifyWithMeta(receipt *Receipt, opts ...VerifyOption) (*VerificationResult, *ResponseMeta, error) {
+	result, meta, err := c.verify(receipt, newCallOptions(opts, VerifyOption.applyVerify))
+	if err != nil {
+		return nil, meta, err
+	}
+	c.fireVerified(result)
+	return result, meta, nil
+}
+
+// VerifyWithPayload is Verify that also checks the receipt covers payload,
+// hashing it with the algorithm named by the payload_hash prefix. A
+// mismatch makes the result invalid.
+func (c *Client) VerifyWithPayload(receipt *Receipt, payload map[string]any, opts ...VerifyOption) (*VerificationResult, error) {
+	result, _, err := c.verify(receipt, newCallOptions(opts, VerifyOption.applyVerify))
+	if err != nil {
+		return nil, err
+	}
+	payloadOK, err := VerifyPayloadHash(payload, receipt.PayloadHash)
+	result.PayloadOK = &payloadOK
+	if !payloadOK {
+		result.Valid = false
+		result.Reason = "Payload does not match payload_hash"
+		if err != nil {
+			result.Reason = fmt.Sprintf("Cannot check payload: %v", err)
+		}
+	}
+	c.fireVerified(result)
+	return result, nil
+}
+
+func (c *Client) verify(receipt *Receipt, co *callOptions) (*VerificationResult, *ResponseMeta, error) {
 	receiptMap := receipt.Raw
 	if receiptMap == nil {
 		data, _ := json.Marshal(receipt)
 		_ = json.Unmarshal(data, &receiptMap)
 	}
 
-	respBody, err := c.doRequest("POST", "/verify", map[string]any{"receipt": receiptMap})
+	respBody, meta, err := c.doCall("POST", "/verify", map[string]any{"receipt": receiptMap}, co)
 	if err != nil {
-		return nil, err
+		return nil, meta, err
 	}
 
 	var result VerificationResult
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, &NotaryError{Message: "failed to parse verification result", Code: "ERR_PARSE"}
+		return nil, meta, &NotaryError{Message: "failed to parse verification result", Code: "ERR_PARSE", Meta: meta}
 	}
 
-	return &result, nil
+	if c.policy != nil {
+		result.Violations = c.policy.evaluateAt(receiptMap, c.Now())
+		policyOK := len(result.Violations) == 0
+		result.PolicyOK = &policyOK
+	}
+
+	return &result, meta, nil
 }
 
 // VerifyByID verifies a receipt by its ID (server-side lookup).
@@ -367,6 +638,7 @@ func (c *Client) VerifyByID(receiptID string) (*VerificationResult, error) {
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, &NotaryError{Message: "failed to parse verification result", Code: "ERR_PARSE"}
 	}
+	c.fireVerified(&result)
 
 	return &result, nil
 }
@@ -433,49 +705,57 @@ type LookupResult struct {
 //	if result.Found && result.Verification.Valid {
 //	    fmt.Println("Receipt is valid!")
 //	}
-func (c *Client) Lookup(receiptHash string) (*LookupResult, error) {
+func (c *Client) Lookup(receiptHash string, opts ...LookupOption) (*LookupResult, error) {
+	result, _, err := c.LookupWithMeta(receiptHash, opts...)
+	return result, err
+}
+
+// LookupWithMeta is Lookup that also returns the response metadata.
+func (c *Client) LookupWithMeta(receiptHash string, opts ...LookupOption) (*LookupResult, *ResponseMeta, error) {
 	url := c.baseURL + "/v1/notary/r/" + receiptHash
+	co := newCallOptions(opts, LookupOption.applyLookup)
+	ctx, cancel := co.context()
+	defer cancel()
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, &NotaryError{Message: fmt.Sprintf("failed to create request: %v", err), Code: "ERR_REQUEST"}
+		return nil, nil, &NotaryError{Message: fmt.Sprintf("failed to create request: %v", err), Code: "ERR_REQUEST"}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "notary-go-sdk/"+SDKVersion)
+	c.setHeaders(req)
+	co.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
-		return nil, &NotaryError{
-			Message: fmt.Sprintf("connection failed: %v", err),
-			Code:    "ERR_CONNECTION",
-		}
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
+	meta := newResponseMeta(resp)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ"}
+		return nil, meta, &NotaryError{Message: "failed to read response", Code: "ERR_READ", Meta: meta}
 	}
 
 	if resp.StatusCode == 404 {
-		return &LookupResult{Found: false}, nil
+		return &LookupResult{Found: false}, meta, nil
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &NotaryError{
+		return nil, meta, &NotaryError{
 			Message: string(respBody),
 			Code:    "ERR_LOOKUP",
 			Status:  resp.StatusCode,
+			Meta:    meta,
 		}
 	}
 
 	var result LookupResult
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, &NotaryError{Message: "failed to parse lookup result", Code: "ERR_PARSE"}
+		return nil, meta, &NotaryError{Message: "failed to parse lookup result", Code: "ERR_PARSE", Meta: meta}
 	}
 
-	return &result, nil
+	return &result, meta, nil
 }
 
 // Counterfactual returns a sub-client for counterfactual receipt operations.
@@ -483,36 +763,24 @@ func (c *Client) Counterfactual() *CounterfactualClient {
 	return &CounterfactualClient{client: c}
 }
 
-// ComputeHash computes SHA-256 of a payload matching server-side hashing.
-// Uses sorted-key JSON serialization for deterministic output.
-func ComputeHash(payload map[string]any) string {
-	// Sort keys for deterministic serialization
-	keys := make([]string, 0, len(payload))
-	for k := range payload {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Build sorted JSON manually with compact separators
-	data, _ := json.Marshal(payload)
-	// json.Marshal already uses compact format, but we need sorted keys
-	// Re-marshal with sorted keys by building ordered map
-	ordered := make([]byte, 0, len(data))
-	ordered = append(ordered, '{')
-	for i, k := range keys {
-		if i > 0 {
-			ordered = append(ordered, ',')
-		}
-		keyBytes, _ := json.Marshal(k)
-		valBytes, _ := json.Marshal(payload[k])
-		ordered = append(ordered, keyBytes...)
-		ordered = append(ordered, ':')
-		ordered = append(ordered, valBytes...)
+// ComputeHash computes the hash of a payload matching server-side hashing.
+// Hashes CanonicalPayload, the payload's sorted-key JSON encoding. SHA-256 is
+// the default; pass an algorithm for a prefixed hash ("sha512:..."). An
+// unsupported algorithm or a payload that cannot be encoded as JSON yields "".
+func ComputeHash(payload map[string]any, alg ...HashAlgorithm) string {
+	algorithm := HashSHA256
+	if len(alg) > 0 && alg[0] != "" {
+		algorithm = alg[0]
 	}
-	ordered = append(ordered, '}')
-
-	hash := sha256.Sum256(ordered)
-	return hex.EncodeToString(hash[:])
+	canonical, err := canonicalPayload(payload)
+	if err != nil {
+		return ""
+	}
+	hash, err := hashBytes(algorithm, canonical)
+	if err != nil {
+		return ""
+	}
+	return hash
 }
 
 // HistoryOptions holds parameters for receipt history queries.
@@ -524,6 +792,27 @@ type HistoryOptions struct {
 	StartDate  string
 	EndDate    string
 	ClerkToken string
+	// AgentID keeps receipts issued by this agent.
+	AgentID string
+	// Tags keeps receipts carrying every listed tag.
+	Tags []string
+	// Fields keeps receipts whose indexed fields have these values.
+	Fields map[string]string
+}
+
+// filterQuery encodes the agent, tag and field filters as query parameters.
+func (o HistoryOptions) filterQuery() string {
+	q := url.Values{}
+	if o.AgentID != "" {
+		q.Set("agent_id", o.AgentID)
+	}
+	for _, tag := range o.Tags {
+		q.Add("tag", tag)
+	}
+	for name, value := range o.Fields {
+		q.Set("field."+name, value)
+	}
+	return q.Encode()
 }
 
 // HistoryResult holds paginated receipt history.
@@ -535,55 +824,69 @@ type HistoryResult struct {
 	PageSize   int              `json:"pageSize"`
 }
 
-// History returns paginated receipt history (requires Clerk JWT).
-func (c *Client) History(opts HistoryOptions) (*HistoryResult, error) {
-	if opts.Page == 0 {
-		opts.Page = 1
+// History returns paginated receipt history (requires Clerk JWT). Pass a
+// HistoryOptions to select the page and filters.
+//
+//	page, err := client.History(notary.HistoryOptions{Page: 2}, notary.WithTimeout(5*time.Second))
+func (c *Client) History(opts ...HistoryOption) (*HistoryResult, error) {
+	return c.history(newCallOptions(opts, HistoryOption.applyHistory))
+}
+
+func (c *Client) history(co *callOptions) (*HistoryResult, error) {
+	query := co.history
+	if query.Page == 0 {
+		query.Page = 1
 	}
-	if opts.PageSize == 0 {
-		opts.PageSize = 10
+	if query.PageSize == 0 {
+		query.PageSize = 10
 	}
 
 	url := fmt.Sprintf("%s/v1/notary/history?page=%d&page_size=%d",
-		c.baseURL, opts.Page, opts.PageSize)
-	if opts.Status != "" {
-		url += "&status=" + opts.Status
+		c.baseURL, query.Page, query.PageSize)
+	if query.Status != "" {
+		url += "&status=" + query.Status
+	}
+	if query.Search != "" {
+		url += "&search=" + query.Search
 	}
-	if opts.Search != "" {
-		url += "&search=" + opts.Search
+	if query.StartDate != "" {
+		url += "&start_date=" + query.StartDate
 	}
-	if opts.StartDate != "" {
-		url += "&start_date=" + opts.StartDate
+	if query.EndDate != "" {
+		url += "&end_date=" + query.EndDate
 	}
-	if opts.EndDate != "" {
-		url += "&end_date=" + opts.EndDate
+	if filters := query.filterQuery(); filters != "" {
+		url += "&" + filters
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	ctx, cancel := co.context()
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, &NotaryError{Message: fmt.Sprintf("failed to create request: %v", err), Code: "ERR_REQUEST"}
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "notary-go-sdk/"+SDKVersion)
-	if opts.ClerkToken != "" {
-		req.Header.Set("Authorization", "Bearer "+opts.ClerkToken)
+	c.setHeaders(req)
+	co.setHeaders(req)
+	if query.ClerkToken != "" {
+		req.Header.Set("Authorization", "Bearer "+query.ClerkToken)
 	} else {
 		req.Header.Set("X-API-Key", c.apiKey)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
-		return nil, &NotaryError{Message: fmt.Sprintf("connection failed: %v", err), Code: "ERR_CONNECTION"}
+		return nil, err
 	}
 	defer resp.Body.Close()
+	meta := newResponseMeta(resp)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ"}
+		return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ", Meta: meta}
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &NotaryError{Message: string(body), Code: "ERR_HISTORY", Status: resp.StatusCode}
+		return nil, &NotaryError{Message: string(body), Code: "ERR_HISTORY", Status: resp.StatusCode, Meta: meta}
 	}
 
 	var result HistoryResult
@@ -593,6 +896,37 @@ func (c *Client) History(opts HistoryOptions) (*HistoryResult, error) {
 	return &result, nil
 }
 
+// Find returns every receipt matching the tag and field filters of the
+// HistoryOptions among opts, paging through History on the server's
+// indexes. Its Page is ignored; other options apply to each page request.
+//
+//	receipts, err := client.Find(notary.HistoryOptions{
+//	    Tags:   []string{"env:prod"},
+//	    Fields: map[string]string{"tenant": "acme"},
+//	})
+func (c *Client) Find(opts ...HistoryOption) ([]map[string]any, error) {
+	co := newCallOptions(opts, HistoryOption.applyHistory)
+	if len(co.history.Tags) == 0 && len(co.history.Fields) == 0 {
+		return nil, &NotaryError{Message: "Find requires a tag or field filter", Code: ErrValidationFailed}
+	}
+	co.history.Page = 1
+	if co.history.PageSize == 0 {
+		co.history.PageSize = 100
+	}
+	var items []map[string]any
+	for {
+		page, err := c.history(co)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, page.Items...)
+		if co.history.Page >= page.TotalPages || len(page.Items) == 0 {
+			return items, nil
+		}
+		co.history.Page++
+	}
+}
+
 // Provenance returns the provenance DAG report for a receipt (public).
 func (c *Client) Provenance(receiptHash string) (map[string]any, error) {
 	url := c.baseURL + "/v1/notary/r/" + receiptHash + "/provenance"
@@ -601,22 +935,22 @@ func (c *Client) Provenance(receiptHash string) (map[string]any, error) {
 	if err != nil {
 		return nil, &NotaryError{Message: fmt.Sprintf("failed to create request: %v", err), Code: "ERR_REQUEST"}
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "notary-go-sdk/"+SDKVersion)
+	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
-		return nil, &NotaryError{Message: fmt.Sprintf("connection failed: %v", err), Code: "ERR_CONNECTION"}
+		return nil, err
 	}
 	defer resp.Body.Close()
+	meta := newResponseMeta(resp)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ"}
+		return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ", Meta: meta}
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &NotaryError{Message: string(body), Code: "ERR_PROVENANCE", Status: resp.StatusCode}
+		return nil, &NotaryError{Message: string(body), Code: "ERR_PROVENANCE", Status: resp.StatusCode, Meta: meta}
 	}
 
 	var result map[string]any
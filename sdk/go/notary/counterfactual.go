@@ -188,18 +188,18 @@ func (c *CounterfactualClient) publicGet(path string) (map[string]any, error) {
 	if err != nil {
 		return nil, &NotaryError{Message: fmt.Sprintf("failed to create request: %v", err), Code: "ERR_REQUEST"}
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "notary-go-sdk/"+SDKVersion)
+	c.client.setHeaders(req)
 
-	resp, err := c.client.httpClient.Do(req)
+	resp, err := c.client.send(req)
 	if err != nil {
-		return nil, &NotaryError{Message: fmt.Sprintf("connection failed: %v", err), Code: "ERR_CONNECTION"}
+		return nil, err
 	}
 	defer resp.Body.Close()
+	meta := newResponseMeta(resp)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ"}
+		return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ", Meta: meta}
 	}
 
 	if resp.StatusCode == 404 {
@@ -207,7 +207,7 @@ func (c *CounterfactualClient) publicGet(path string) (map[string]any, error) {
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &NotaryError{Message: string(respBody), Code: "ERR_REQUEST", Status: resp.StatusCode}
+		return nil, &NotaryError{Message: string(respBody), Code: "ERR_REQUEST", Status: resp.StatusCode, Meta: meta}
 	}
 
 	var result map[string]any
@@ -0,0 +1,197 @@
+package notary
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// CounterfactualItem is a typed entry from an agent's counterfactual list.
+type CounterfactualItem struct {
+	ReceiptHash           string         `json:"receipt_hash"`
+	ReceiptID             string         `json:"receipt_id"`
+	AgentID               string         `json:"agent_id"`
+	ActionNotTaken        string         `json:"action_not_taken"`
+	DeclinationReason     string         `json:"declination_reason"`
+	Timestamp             string         `json:"timestamp"`
+	ValidityWindowMinutes int            `json:"validity_window_minutes"`
+	Phase                 string         `json:"phase,omitempty"`
+	Revealed              bool           `json:"revealed"`
+	Raw                   map[string]any `json:"-"`
+}
+
+// Time parses Timestamp (zero if missing or malformed).
+func (i *CounterfactualItem) Time() time.Time {
+	t, _ := time.Parse(time.RFC3339, i.Timestamp)
+	return t
+}
+
+// CounterfactualPage is one typed page of ListByAgent results.
+type CounterfactualPage struct {
+	Items  []CounterfactualItem
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// CounterfactualFilter narrows counterfactual listings. The list endpoint
+// only pages by limit/offset, so every filter here is applied client-side.
+type CounterfactualFilter struct {
+	// ActionNotTakenPrefix keeps items whose action_not_taken starts with it.
+	ActionNotTakenPrefix string
+	// Since and Until bound the receipt timestamp (zero = unbounded).
+	Since time.Time
+	Until time.Time
+	// RevealedOnly keeps only commit-reveal receipts that have been revealed.
+	RevealedOnly bool
+	// PageSize is the server page size (default 50).
+	PageSize int
+}
+
+func (f *CounterfactualFilter) match(item *CounterfactualItem) bool {
+	if f == nil {
+		return true
+	}
+	if f.ActionNotTakenPrefix != "" && !strings.HasPrefix(item.ActionNotTaken, f.ActionNotTakenPrefix) {
+		return false
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		t := item.Time()
+		if t.IsZero() || (!f.Since.IsZero() && t.Before(f.Since)) || (!f.Until.IsZero() && t.After(f.Until)) {
+			return false
+		}
+	}
+	if f.RevealedOnly && !item.Revealed {
+		return false
+	}
+	return true
+}
+
+// ListPage is ListByAgent with typed results.
+func (c *CounterfactualClient) ListPage(agentID string, limit, offset int) (*CounterfactualPage, error) {
+	if limit == 0 {
+		limit = 50
+	}
+	raw, err := c.ListByAgent(agentID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CounterfactualPage{Limit: limit, Offset: offset}
+	list, ok := raw["receipts"].([]any)
+	if !ok {
+		list, _ = raw["items"].([]any)
+	}
+	for _, entry := range list {
+		m, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		page.Items = append(page.Items, parseCounterfactualItem(m))
+	}
+	if total, ok := raw["total"].(float64); ok {
+		page.Total = int(total)
+	} else {
+		page.Total = -1
+	}
+	return page, nil
+}
+
+func parseCounterfactualItem(m map[string]any) CounterfactualItem {
+	var item CounterfactualItem
+	data, _ := json.Marshal(m)
+	_ = json.Unmarshal(data, &item)
+	item.Raw = m
+
+	if item.Timestamp == "" {
+		item.Timestamp = getString(m, "issued_at")
+	}
+	if cr, ok := m["commit_reveal"].(map[string]any); ok && item.Phase == "" {
+		item.Phase = getString(cr, "phase")
+	}
+	if strings.EqualFold(item.Phase, "revealed") {
+		item.Revealed = true
+	}
+	return item
+}
+
+// CounterfactualIterator pages through an agent's counterfactual receipts.
+//
+//	it := client.Counterfactual().Iterate("agent-1", &notary.CounterfactualFilter{
+//	    ActionNotTakenPrefix: "financial.",
+//	    RevealedOnly:         true,
+//	})
+//	for it.Next() {
+//	    fmt.Println(it.Item().ActionNotTaken)
+//	}
+//	if err := it.Err(); err != nil {
+//	    log.Fatal(err)
+//	}
+type CounterfactualIterator struct {
+	cf      *CounterfactualClient
+	agentID string
+	filter  *CounterfactualFilter
+	limit   int
+	offset  int
+	buf     []CounterfactualItem
+	cur     CounterfactualItem
+	done    bool
+	err     error
+}
+
+// Iterate returns an iterator over an agent's counterfactual receipts.
+// Pass nil for filter to list everything.
+func (c *CounterfactualClient) Iterate(agentID string, filter *CounterfactualFilter) *CounterfactualIterator {
+	limit := 50
+	if filter != nil && filter.PageSize > 0 {
+		limit = filter.PageSize
+	}
+	return &CounterfactualIterator{cf: c, agentID: agentID, filter: filter, limit: limit}
+}
+
+// Next advances to the next matching item, fetching pages as needed.
+func (it *CounterfactualIterator) Next() bool {
+	for {
+		for len(it.buf) > 0 {
+			item := it.buf[0]
+			it.buf = it.buf[1:]
+			if it.filter.match(&item) {
+				it.cur = item
+				return true
+			}
+		}
+		if it.done || it.err != nil {
+			return false
+		}
+
+		page, err := it.cf.ListPage(it.agentID, it.limit, it.offset)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.buf = page.Items
+		it.offset += len(page.Items)
+		if len(page.Items) < it.limit || (page.Total >= 0 && it.offset >= page.Total) {
+			it.done = true
+		}
+	}
+}
+
+// Item returns the current item.
+func (it *CounterfactualIterator) Item() CounterfactualItem {
+	return it.cur
+}
+
+// Err returns the first error encountered while paging.
+func (it *CounterfactualIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator into a slice.
+func (it *CounterfactualIterator) All() ([]CounterfactualItem, error) {
+	var out []CounterfactualItem
+	for it.Next() {
+		out = append(out, it.Item())
+	}
+	return out, it.Err()
+}
@@ -0,0 +1,330 @@
+// Package gitattest issues NotaryOS receipts that bind a git commit — and,
+// for release tags, the tag and its signature — so release pipelines can
+// prove later that a published artifact came from exactly that source.
+//
+// Objects are read straight from the repository's .git directory (loose
+// objects and packfiles); no git binary is needed.
+//
+//	receipt, att, err := gitattest.Attest(client, ".", "v1.4.0", nil)
+//	...
+//	result, err := gitattest.Verify(client, receipt, ".", "v1.4.0")
+//	if !result.Valid { log.Fatal(result.Reason) }
+package gitattest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hellothere012/notaryos-go/notary"
+)
+
+// ActionType is the action type of git attestation receipts.
+const ActionType = "git.attest"
+
+// ErrBindingMismatch is returned by VerifyBinding when the repository no
+// longer matches the receipt.
+var ErrBindingMismatch = errors.New("gitattest: receipt does not match repository")
+
+// Attestation is the git state bound by a receipt. Identities are the raw
+// git ident lines ("Name <email> 1700000000 +0000").
+type Attestation struct {
+	Commit    string   `json:"commit"`
+	Tree      string   `json:"tree"`
+	Parents   []string `json:"parents,omitempty"`
+	Author    string   `json:"author"`
+	Committer string   `json:"committer"`
+	// Tag fields are set when the revision is an annotated tag.
+	Tag          string `json:"tag,omitempty"`
+	TagObject    string `json:"tag_object,omitempty"`
+	Tagger       string `json:"tagger,omitempty"`
+	TagSignature string `json:"tag_signature,omitempty"`
+}
+
+// Signed reports whether the attested tag carries a signature.
+func (a *Attestation) Signed() bool {
+	return a.TagSignature != ""
+}
+
+// Payload returns the receipt payload for a. The tag signature is bound by
+// its SHA-256 digest; the tag object ID already commits to the full text.
+func (a *Attestation) Payload() map[string]any {
+	parents := make([]any, len(a.Parents))
+	for i, p := range a.Parents {
+		parents[i] = p
+	}
+	payload := map[string]any{
+		"commit":    a.Commit,
+		"tree":      a.Tree,
+		"parents":   parents,
+		"author":    a.Author,
+		"committer": a.Committer,
+	}
+	if a.TagObject != "" {
+		payload["tag"] = a.Tag
+		payload["tag_object"] = a.TagObject
+		payload["tagger"] = a.Tagger
+	}
+	if a.TagSignature != "" {
+		sum := sha256.Sum256([]byte(a.TagSignature))
+		payload["tag_signature_sha256"] = hex.EncodeToString(sum[:])
+	}
+	return payload
+}
+
+// Inspect reads the attestation for rev from the repository at repoPath.
+// rev may be a full commit or tag SHA, "HEAD", a branch or tag name, or a
+// full ref name; "" means HEAD.
+func Inspect(repoPath, rev string) (*Attestation, error) {
+	r, err := openRepo(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+	id, err := r.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	att := &Attestation{}
+	for hops := 0; ; hops++ {
+		typ, body, err := r.readObject(id)
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case "commit":
+			if err := parseCommit(body, att); err != nil {
+				return nil, fmt.Errorf("commit %s: %w", id, err)
+			}
+			att.Commit = id
+			return att, nil
+		case "tag":
+			target, err := parseTag(body, att, hops == 0)
+			if err != nil {
+				return nil, fmt.Errorf("tag %s: %w", id, err)
+			}
+			if hops == 0 {
+				att.TagObject = id
+			}
+			if hops > 8 {
+				return nil, fmt.Errorf("tag %s: too many nested tags", att.TagObject)
+			}
+			id = target
+		default:
+			return nil, fmt.Errorf("%s is a %s, not a commit or tag", rev, typ)
+		}
+	}
+}
+
+// Options configures Attest.
+type Options struct {
+	// RequireSignedTag fails unless rev is an annotated tag with a signature.
+	RequireSignedTag bool
+	// PreviousReceiptHash chains the receipt (see notary.WithPreviousHash).
+	PreviousReceiptHash string
+	// Metadata is added to the receipt metadata alongside git_commit and git_tag.
+	Metadata map[string]any
+}
+
+// Attest reads rev from the repository at repoPath and issues a receipt
+// binding it. The commit and tag name are also recorded in the receipt
+// metadata so Verify can locate them later.
+func Attest(client *notary.Client, repoPath, rev string, opts *Options) (*notary.Receipt, *Attestation, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	att, err := Inspect(repoPath, rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.RequireSignedTag && !att.Signed() {
+		return nil, att, fmt.Errorf("gitattest: %s is not a signed tag", rev)
+	}
+
+	metadata := make(map[string]any, len(opts.Metadata)+2)
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	metadata["git_commit"] = att.Commit
+	if att.Tag != "" {
+		metadata["git_tag"] = att.Tag
+	}
+
+	receipt, err := client.Issue(ActionType, att.Payload(),
+		notary.WithPreviousHash(opts.PreviousReceiptHash),
+		notary.WithMetadata(metadata),
+	)
+	return receipt, att, err
+}
+
+// VerifyBinding re-reads rev and checks that the receipt binds exactly that
+// state, without contacting the API. An empty rev uses the git_tag or
+// git_commit recorded in the receipt metadata.
+func VerifyBinding(receipt *notary.Receipt, repoPath, rev string) (*Attestation, error) {
+	if receipt.ActionType != ActionType {
+		return nil, fmt.Errorf("%w: action type is %q, not %q", ErrBindingMismatch, receipt.ActionType, ActionType)
+	}
+	if rev == "" {
+		rev = revFromReceipt(receipt)
+		if rev == "" {
+			return nil, fmt.Errorf("gitattest: no revision given and none recorded in the receipt")
+		}
+	}
+	att, err := Inspect(repoPath, rev)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := notary.VerifyPayloadHash(att.Payload(), receipt.PayloadHash)
+	if err != nil {
+		return att, err
+	}
+	if !ok {
+		return att, fmt.Errorf("%w: %s does not hash to %s", ErrBindingMismatch, rev, receipt.PayloadHash)
+	}
+	return att, nil
+}
+
+// Result is the outcome of Verify.
+type Result struct {
+	// Valid is true when both the binding and the receipt signature check out.
+	Valid bool
+	// BindingOK is true when the repository still matches the receipt payload.
+	BindingOK bool
+	// Receipt is the service's verification of the receipt itself.
+	Receipt     *notary.VerificationResult
+	Attestation *Attestation
+	Reason      string
+}
+
+// Verify checks that the receipt is authentic (via client.Verify) and that
+// it still binds rev in the repository at repoPath.
+func Verify(client *notary.Client, receipt *notary.Receipt, repoPath, rev string) (*Result, error) {
+	result := &Result{}
+	att, err := VerifyBinding(receipt, repoPath, rev)
+	result.Attestation = att
+	if err != nil {
+		if !errors.Is(err, ErrBindingMismatch) {
+			return nil, err
+		}
+		result.Reason = err.Error()
+	} else {
+		result.BindingOK = true
+	}
+
+	verification, err := client.Verify(receipt)
+	if err != nil {
+		return nil, err
+	}
+	result.Receipt = verification
+	result.Valid = result.BindingOK && verification.Valid
+	if result.Reason == "" && !verification.Valid {
+		result.Reason = verification.Reason
+	}
+	return result, nil
+}
+
+func revFromReceipt(receipt *notary.Receipt) string {
+	metadata, _ := receipt.Raw["metadata"].(map[string]any)
+	if tag, _ := metadata["git_tag"].(string); tag != "" {
+		return "refs/tags/" + tag
+	}
+	commit, _ := metadata["git_commit"].(string)
+	return commit
+}
+
+// parseCommit fills the commit fields of att from a commit object body.
+func parseCommit(body []byte, att *Attestation) error {
+	headers, _ := splitObject(body)
+	for _, h := range headers {
+		switch h.key {
+		case "tree":
+			att.Tree = h.value
+		case "parent":
+			att.Parents = append(att.Parents, h.value)
+		case "author":
+			att.Author = h.value
+		case "committer":
+			att.Committer = h.value
+		}
+	}
+	if att.Tree == "" || att.Author == "" {
+		return errors.New("malformed commit object")
+	}
+	return nil
+}
+
+// parseTag returns the tag's target, filling the tag fields of att when
+// outer is set (nested tags only contribute their target).
+func parseTag(body []byte, att *Attestation, outer bool) (string, error) {
+	headers, message := splitObject(body)
+	var target, gpgsig string
+	for _, h := range headers {
+		switch h.key {
+		case "object":
+			target = h.value
+		case "tag":
+			if outer {
+				att.Tag = h.value
+			}
+		case "tagger":
+			if outer {
+				att.Tagger = h.value
+			}
+		case "gpgsig":
+			gpgsig = h.value
+		}
+	}
+	if !isHex40(target) {
+		return "", errors.New("malformed tag object")
+	}
+	if outer {
+		att.TagSignature = tagSignature(message)
+		if att.TagSignature == "" {
+			att.TagSignature = gpgsig
+		}
+	}
+	return target, nil
+}
+
+// tagSignature returns the armored signature appended to a tag message
+// (OpenPGP, SSH, or X.509), or "".
+func tagSignature(message []byte) string {
+	for _, marker := range []string{
+		"-----BEGIN PGP SIGNATURE-----",
+		"-----BEGIN SSH SIGNATURE-----",
+		"-----BEGIN SIGNED MESSAGE-----",
+	} {
+		i := bytes.LastIndex(message, []byte(marker))
+		if i >= 0 && (i == 0 || message[i-1] == '\n') {
+			return string(message[i:])
+		}
+	}
+	return ""
+}
+
+type objectHeader struct {
+	key, value string
+}
+
+// splitObject splits a commit or tag body into its headers (continuation
+// lines joined with "\n") and message.
+func splitObject(body []byte) ([]objectHeader, []byte) {
+	head, message, _ := bytes.Cut(body, []byte("\n\n"))
+	var headers []objectHeader
+	for _, line := range strings.Split(string(head), "\n") {
+		if strings.HasPrefix(line, " ") && len(headers) > 0 {
+			last := &headers[len(headers)-1]
+			last.value += "\n" + line[1:]
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		headers = append(headers, objectHeader{key: key, value: value})
+	}
+	return headers, message
+}
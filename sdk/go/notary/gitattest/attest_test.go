@@ -0,0 +1,66 @@
+package gitattest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hellothere012/notaryos-go/notary"
+	"github.com/hellothere012/notaryos-go/notarytest"
+)
+
+func TestVerifyBindingFixture(t *testing.T) {
+	srv := notarytest.NewMockServer(nil)
+	defer srv.Close()
+	client, err := notary.NewClient("notary_test_key", &notary.Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receipt, att, err := Attest(client, fixture, "v1.0.0", &Options{RequireSignedTag: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Commit != fixtureTagged {
+		t.Fatalf("attested commit %s, want %s", att.Commit, fixtureTagged)
+	}
+	if _, err := VerifyBinding(receipt, fixture, "refs/tags/v1.0.0"); err != nil {
+		t.Errorf("VerifyBinding(v1.0.0) = %v", err)
+	}
+	// HEAD has moved on since the release.
+	if _, err := VerifyBinding(receipt, fixture, "HEAD"); !errors.Is(err, ErrBindingMismatch) {
+		t.Errorf("VerifyBinding(HEAD) error = %v, want ErrBindingMismatch", err)
+	}
+
+	result, err := Verify(client, receipt, fixture, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || !result.BindingOK {
+		t.Errorf("Verify = valid %v, binding %v: %s", result.Valid, result.BindingOK, result.Reason)
+	}
+}
+
+func TestVerifyBindingRevFromMetadata(t *testing.T) {
+	head, err := Inspect(fixture, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := &notary.Receipt{
+		ActionType:  ActionType,
+		PayloadHash: notary.ComputeHash(head.Payload()),
+		Raw:         map[string]any{"metadata": map[string]any{"git_commit": fixtureHead}},
+	}
+	if _, err := VerifyBinding(receipt, fixture, ""); err != nil {
+		t.Errorf("VerifyBinding with the recorded commit = %v", err)
+	}
+
+	receipt.Raw["metadata"] = map[string]any{"git_commit": fixtureTagged}
+	if _, err := VerifyBinding(receipt, fixture, ""); !errors.Is(err, ErrBindingMismatch) {
+		t.Errorf("VerifyBinding with another commit = %v, want ErrBindingMismatch", err)
+	}
+
+	receipt.ActionType = "billing.charge"
+	if _, err := VerifyBinding(receipt, fixture, fixtureHead); !errors.Is(err, ErrBindingMismatch) {
+		t.Errorf("VerifyBinding with a foreign action type = %v, want ErrBindingMismatch", err)
+	}
+}
@@ -0,0 +1,425 @@
+package gitattest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// errNotFound is returned when an object or ref does not exist.
+var errNotFound = errors.New("not found")
+
+// repo reads objects and refs straight from a .git directory.
+type repo struct {
+	gitDir    string // HEAD and per-worktree refs
+	commonDir string // objects, shared refs and packed-refs
+	packs     []*pack
+}
+
+// openRepo locates the git directory for path: a worktree (path/.git
+// directory or "gitdir:" file) or a bare repository.
+func openRepo(path string) (*repo, error) {
+	gitDir := path
+	dotGit := filepath.Join(path, ".git")
+	if info, err := os.Stat(dotGit); err == nil {
+		if info.IsDir() {
+			gitDir = dotGit
+		} else {
+			data, err := os.ReadFile(dotGit)
+			if err != nil {
+				return nil, err
+			}
+			target := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(path, target)
+			}
+			gitDir = target
+		}
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		return nil, fmt.Errorf("%s is not a git repository", path)
+	}
+	// Linked worktrees keep objects and shared refs in the common dir.
+	r := &repo{gitDir: gitDir, commonDir: gitDir}
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		dir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(gitDir, dir)
+		}
+		r.commonDir = dir
+	}
+	if cfg, err := os.ReadFile(filepath.Join(r.commonDir, "config")); err == nil &&
+		strings.Contains(strings.ToLower(string(cfg)), "objectformat = sha256") {
+		return nil, fmt.Errorf("SHA-256 object format repositories are not supported")
+	}
+	return r, r.loadPacks()
+}
+
+func (r *repo) loadPacks() error {
+	idxFiles, _ := filepath.Glob(filepath.Join(r.commonDir, "objects", "pack", "*.idx"))
+	for _, idx := range idxFiles {
+		p, err := openPack(strings.TrimSuffix(idx, ".idx"))
+		if err != nil {
+			return err
+		}
+		r.packs = append(r.packs, p)
+	}
+	return nil
+}
+
+// resolve turns a revision (full SHA, HEAD, branch, tag, or full ref name)
+// into an object ID. Tags resolve to the tag object, not its target.
+func (r *repo) resolve(rev string) (string, error) {
+	if isHex40(rev) {
+		return strings.ToLower(rev), nil
+	}
+	candidates := []string{rev}
+	if !strings.HasPrefix(rev, "refs/") && rev != "HEAD" {
+		candidates = []string{"refs/tags/" + rev, "refs/heads/" + rev, "refs/remotes/" + rev}
+	}
+	for _, name := range candidates {
+		id, err := r.readRef(name, 0)
+		if err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, errNotFound) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("revision %q: %w", rev, errNotFound)
+}
+
+func (r *repo) readRef(name string, depth int) (string, error) {
+	if depth > 10 {
+		return "", fmt.Errorf("ref %s: symbolic ref loop", name)
+	}
+	data, err := os.ReadFile(filepath.Join(r.gitDir, filepath.FromSlash(name)))
+	if os.IsNotExist(err) && r.commonDir != r.gitDir {
+		data, err = os.ReadFile(filepath.Join(r.commonDir, filepath.FromSlash(name)))
+	}
+	if err == nil {
+		value := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(value, "ref:"); ok {
+			return r.readRef(strings.TrimSpace(target), depth+1)
+		}
+		if isHex40(value) {
+			return value, nil
+		}
+		return "", fmt.Errorf("ref %s: malformed value", name)
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	return r.packedRef(name)
+}
+
+func (r *repo) packedRef(name string) (string, error) {
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return "", errNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		id, ref, ok := strings.Cut(line, " ")
+		if ok && ref == name && isHex40(id) {
+			return id, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errNotFound
+}
+
+// readObject returns an object's type ("commit", "tree", "blob", "tag") and
+// body, after checking that they hash to id: the attestation is only as good
+// as the objects it is built from.
+func (r *repo) readObject(id string) (string, []byte, error) {
+	typ, body, err := r.readStored(id)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha1.Sum(append([]byte(fmt.Sprintf("%s %d\x00", typ, len(body))), body...))
+	if hex.EncodeToString(sum[:]) != id {
+		return "", nil, fmt.Errorf("object %s: content hash mismatch", id)
+	}
+	return typ, body, nil
+}
+
+// readStored reads an object from a loose file or a pack, unverified.
+func (r *repo) readStored(id string) (string, []byte, error) {
+	path := filepath.Join(r.commonDir, "objects", id[:2], id[2:])
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		zr, err := zlib.NewReader(f)
+		if err != nil {
+			return "", nil, fmt.Errorf("object %s: %w", id, err)
+		}
+		defer zr.Close()
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			return "", nil, fmt.Errorf("object %s: %w", id, err)
+		}
+		header, body, ok := bytes.Cut(data, []byte{0})
+		if !ok {
+			return "", nil, fmt.Errorf("object %s: malformed header", id)
+		}
+		typ, size, _ := strings.Cut(string(header), " ")
+		if n, err := strconv.Atoi(size); err != nil || n != len(body) {
+			return "", nil, fmt.Errorf("object %s: size mismatch", id)
+		}
+		return typ, body, nil
+	}
+
+	raw, err := hex.DecodeString(id)
+	if err != nil {
+		return "", nil, fmt.Errorf("object %s: invalid id", id)
+	}
+	for _, p := range r.packs {
+		if offset, ok := p.find(raw); ok {
+			return p.readAt(r, offset, 0)
+		}
+	}
+	return "", nil, fmt.Errorf("object %s: %w", id, errNotFound)
+}
+
+// pack is a version 2 pack index plus its packfile.
+type pack struct {
+	path    string
+	fanout  [256]uint32
+	ids     []byte // sorted 20-byte object IDs
+	offsets []byte // 4-byte offsets (MSB set = index into large)
+	large   []byte // 8-byte offsets
+}
+
+func openPack(base string) (*pack, error) {
+	idx, err := os.ReadFile(base + ".idx")
+	if err != nil {
+		return nil, err
+	}
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(idx[4:8]) != 2 {
+		return nil, fmt.Errorf("%s.idx: unsupported pack index version", base)
+	}
+	p := &pack{path: base + ".pack"}
+	for i := 0; i < 256; i++ {
+		p.fanout[i] = binary.BigEndian.Uint32(idx[8+i*4:])
+	}
+	n := int(p.fanout[255])
+	pos := 8 + 256*4
+	if len(idx) < pos+n*(20+4+4) {
+		return nil, fmt.Errorf("%s.idx: truncated", base)
+	}
+	p.ids = idx[pos : pos+n*20]
+	pos += n * 20
+	pos += n * 4 // CRC32s
+	p.offsets = idx[pos : pos+n*4]
+	pos += n * 4
+	p.large = idx[pos:]
+	return p, nil
+}
+
+func (p *pack) find(id []byte) (int64, bool) {
+	lo := 0
+	if id[0] > 0 {
+		lo = int(p.fanout[id[0]-1])
+	}
+	hi := int(p.fanout[id[0]])
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch bytes.Compare(p.ids[mid*20:mid*20+20], id) {
+		case 0:
+			off := binary.BigEndian.Uint32(p.offsets[mid*4:])
+			if off&0x80000000 == 0 {
+				return int64(off), true
+			}
+			li := int(off&0x7fffffff) * 8
+			if li+8 > len(p.large) {
+				return 0, false
+			}
+			return int64(binary.BigEndian.Uint64(p.large[li:])), true
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, false
+}
+
+var packTypes = map[byte]string{1: "commit", 2: "tree", 3: "blob", 4: "tag"}
+
+const (
+	packOfsDelta = 6
+	packRefDelta = 7
+	maxDeltaHops = 64
+)
+
+func (p *pack) readAt(r *repo, offset int64, depth int) (string, []byte, error) {
+	if depth > maxDeltaHops {
+		return "", nil, fmt.Errorf("%s: delta chain too deep", p.path)
+	}
+	f, err := os.Open(p.path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+	c, err := br.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	typ := (c >> 4) & 7
+	for c&0x80 != 0 { // object size; the inflated length is checked instead
+		if c, err = br.ReadByte(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var baseType string
+	var base []byte
+	switch typ {
+	case packOfsDelta:
+		c, err := br.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			rel = ((rel + 1) << 7) | int64(c&0x7f)
+		}
+		baseType, base, err = p.readAt(r, offset-rel, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+	case packRefDelta:
+		baseID := make([]byte, 20)
+		if _, err := io.ReadFull(br, baseID); err != nil {
+			return "", nil, err
+		}
+		baseType, base, err = r.readObject(hex.EncodeToString(baseID))
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	zr, err := zlib.NewReader(br)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", p.path, err)
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", p.path, err)
+	}
+
+	if base != nil {
+		out, err := applyDelta(base, data)
+		return baseType, out, err
+	}
+	name, ok := packTypes[typ]
+	if !ok {
+		return "", nil, fmt.Errorf("%s: unknown object type %d", p.path, typ)
+	}
+	return name, data, nil
+}
+
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errBad := errors.New("malformed delta")
+	readSize := func() (int, bool) {
+		size, shift := 0, 0
+		for len(delta) > 0 {
+			c := delta[0]
+			delta = delta[1:]
+			size |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+
+	srcSize, ok1 := readSize()
+	dstSize, ok2 := readSize()
+	if !ok1 || !ok2 || srcSize != len(base) {
+		return nil, errBad
+	}
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		if op&0x80 != 0 {
+			var off, size int
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errBad
+					}
+					off |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := 0; i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errBad
+					}
+					size |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if off+size > len(base) {
+				return nil, errBad
+			}
+			out = append(out, base[off:off+size]...)
+		} else if op != 0 {
+			n := int(op)
+			if n > len(delta) {
+				return nil, errBad
+			}
+			out = append(out, delta[:n]...)
+			delta = delta[n:]
+		} else {
+			return nil, errBad
+		}
+	}
+	if len(out) != dstSize {
+		return nil, errBad
+	}
+	return out, nil
+}
+
+func isHex40(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
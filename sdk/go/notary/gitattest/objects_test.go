@@ -0,0 +1,278 @@
+package gitattest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixture is built by testdata/make-fixture.sh.
+const fixture = "testdata/fixture.git"
+
+const (
+	fixtureInitial = "8d8f1534caafa054a555b7d6f6271454ba2a758d" // packed
+	fixtureTagged  = "ccacabdc4d4f9ae4f50974c9d1f71046d14ceb6b" // packed, tagged v1.0.0
+	fixtureHead    = "50ebfa0784cfa92457415bebd84bdedb1999beed" // loose, refs/heads/main
+	fixtureTag     = "e0a746a1022489ee7ab54a34df9e1102f538c50a" // annotated tag object
+)
+
+// TestReadObjectsFixture reads every loose and packed object in the fixture
+// and checks it against its ID, so a bad inflate, delta or header parse
+// cannot go unnoticed.
+func TestReadObjectsFixture(t *testing.T) {
+	r, err := openRepo(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.packs) != 1 {
+		t.Fatalf("loaded %d packs, want 1", len(r.packs))
+	}
+
+	loose, err := filepath.Glob(filepath.Join(fixture, "objects", "??", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, path := range loose {
+		ids = append(ids, filepath.Base(filepath.Dir(path))+filepath.Base(path))
+	}
+	p := r.packs[0]
+	for i := 0; i < len(p.ids)/20; i++ {
+		ids = append(ids, hex.EncodeToString(p.ids[i*20:i*20+20]))
+	}
+
+	types := make(map[string]int)
+	for _, id := range ids {
+		typ, body, err := r.readObject(id)
+		if err != nil {
+			t.Errorf("readObject(%s): %v", id, err)
+			continue
+		}
+		sum := sha1.Sum(append([]byte(fmt.Sprintf("%s %d\x00", typ, len(body))), body...))
+		if got := hex.EncodeToString(sum[:]); got != id {
+			t.Errorf("object %s: content hashes to %s", id, got)
+		}
+		types[typ]++
+	}
+	for _, typ := range []string{"commit", "tree", "blob", "tag"} {
+		if types[typ] == 0 {
+			t.Errorf("fixture has no %s objects", typ)
+		}
+	}
+	if len(loose) == 0 {
+		t.Error("fixture has no loose objects")
+	}
+}
+
+// copyFixture copies the fixture repository into a temporary directory so a
+// test can damage it.
+func copyFixture(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "fixture.git")
+	err := filepath.Walk(fixture, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(fixture, path)
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), data, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestReadObjectRejectsCorruptLoose rewrites a loose commit with a
+// well-formed header and a changed body; only the ID check can catch it.
+func TestReadObjectRejectsCorruptLoose(t *testing.T) {
+	dir := copyFixture(t)
+	path := filepath.Join(dir, "objects", fixtureHead[:2], fixtureHead[2:])
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := bytes.Replace(data, []byte("Append line 401"), []byte("Append line 999"), 1)
+	if bytes.Equal(forged, data) {
+		t.Fatal("fixture HEAD commit message changed; update the test")
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(forged)
+	zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := openRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.readObject(fixtureHead); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Errorf("readObject of a forged loose object = %v, want a hash mismatch", err)
+	}
+	if _, err := Inspect(dir, ""); err == nil {
+		t.Error("Inspect accepted a forged HEAD commit")
+	}
+}
+
+// TestReadObjectRejectsCorruptPacked points two pack index entries at each
+// other's data, so each packed commit inflates cleanly but to the wrong body.
+// The index is only changed in memory.
+func TestReadObjectRejectsCorruptPacked(t *testing.T) {
+	r, err := openRepo(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := r.packs[0]
+	index := func(id string) int {
+		raw, _ := hex.DecodeString(id)
+		for i := 0; i < len(p.ids)/20; i++ {
+			if bytes.Equal(p.ids[i*20:i*20+20], raw) {
+				return i
+			}
+		}
+		t.Fatalf("%s is not in the pack", id)
+		return 0
+	}
+	a, b := index(fixtureInitial), index(fixtureTagged)
+	offA := binary.BigEndian.Uint32(p.offsets[a*4:])
+	binary.BigEndian.PutUint32(p.offsets[a*4:], binary.BigEndian.Uint32(p.offsets[b*4:]))
+	binary.BigEndian.PutUint32(p.offsets[b*4:], offA)
+
+	for _, id := range []string{fixtureInitial, fixtureTagged} {
+		if _, _, err := r.readObject(id); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+			t.Errorf("readObject(%s) from a swapped index = %v, want a hash mismatch", id, err)
+		}
+	}
+}
+
+// TestPackHasOfsDelta guards the fixture itself: without an ofs-delta the
+// delta path would go untested.
+func TestPackHasOfsDelta(t *testing.T) {
+	r, err := openRepo(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := r.packs[0]
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deltas := 0
+	for i := 0; i < len(p.ids)/20; i++ {
+		offset, ok := p.find(p.ids[i*20 : i*20+20])
+		if !ok {
+			t.Fatalf("pack index entry %d not found", i)
+		}
+		if (data[offset]>>4)&7 == packOfsDelta {
+			deltas++
+		}
+	}
+	if deltas == 0 {
+		t.Fatal("fixture pack has no ofs-delta objects; rebuild it with make-fixture.sh")
+	}
+}
+
+func TestResolveFixture(t *testing.T) {
+	r, err := openRepo(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		rev, want string
+	}{
+		{"HEAD", fixtureHead},
+		{"main", fixtureHead}, // loose ref overrides packed-refs
+		{"refs/heads/main", fixtureHead},
+		{"v1.0.0", fixtureTag}, // packed-refs only
+		{"refs/tags/v1.0.0", fixtureTag},
+		{strings.ToUpper(fixtureInitial), fixtureInitial},
+	} {
+		got, err := r.resolve(tc.rev)
+		if err != nil || got != tc.want {
+			t.Errorf("resolve(%q) = %s, %v; want %s", tc.rev, got, err, tc.want)
+		}
+	}
+	if _, err := r.resolve("missing"); !errors.Is(err, errNotFound) {
+		t.Errorf("resolve(missing) error = %v, want errNotFound", err)
+	}
+}
+
+func TestInspectFixture(t *testing.T) {
+	att, err := Inspect(fixture, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Commit != fixtureTagged || att.TagObject != fixtureTag || att.Tag != "v1.0.0" {
+		t.Errorf("Inspect(v1.0.0) = commit %s, tag %s %q", att.Commit, att.TagObject, att.Tag)
+	}
+	if len(att.Parents) != 1 || att.Parents[0] != fixtureInitial {
+		t.Errorf("parents = %v, want [%s]", att.Parents, fixtureInitial)
+	}
+	if !att.Signed() || !strings.HasPrefix(att.TagSignature, "-----BEGIN PGP SIGNATURE-----") {
+		t.Errorf("tag signature = %q", att.TagSignature)
+	}
+	if !strings.HasPrefix(att.Tagger, "Fixture Committer <committer@example.com> 1704240000 +0000") {
+		t.Errorf("tagger = %q", att.Tagger)
+	}
+
+	head, err := Inspect(fixture, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Commit != fixtureHead || head.TagObject != "" {
+		t.Errorf("Inspect(HEAD) = commit %s, tag %s", head.Commit, head.TagObject)
+	}
+	if len(head.Parents) != 1 || head.Parents[0] != fixtureTagged {
+		t.Errorf("HEAD parents = %v, want [%s]", head.Parents, fixtureTagged)
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello world")
+	// Copy base[0:5], then insert "-there".
+	delta := []byte{11, 11, 0x90, 5, 6, '-', 't', 'h', 'e', 'r', 'e'}
+	out, err := applyDelta(base, delta)
+	if err != nil || string(out) != "hello-there" {
+		t.Fatalf("applyDelta = %q, %v", out, err)
+	}
+
+	for name, bad := range map[string][]byte{
+		"source size":    {10, 5, 0x90, 5},
+		"result size":    {11, 6, 0x90, 5},
+		"copy past base": {11, 5, 0x91, 8, 5},
+		"short insert":   {11, 5, 5, 'a'},
+		"zero opcode":    {11, 0, 0},
+		"truncated copy": {11, 5, 0x90},
+		"truncated size": {0x80},
+	} {
+		if _, err := applyDelta(base, bad); err == nil {
+			t.Errorf("%s: applyDelta accepted a malformed delta", name)
+		}
+	}
+}
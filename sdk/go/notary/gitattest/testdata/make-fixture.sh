@@ -0,0 +1,54 @@
+#!/bin/sh
+# Rebuild testdata/fixture.git, the repository the object reader tests run
+# against. Dates and identities are fixed, so object IDs are stable across
+# git versions; only the pack layout may differ.
+#
+# Layout: two commits and an annotated tag packed by an aggressive gc (the
+# second a.txt is an ofs-delta against the first, refs are in packed-refs),
+# then a third commit left loose on refs/heads/main.
+set -eu
+
+here=$(cd "$(dirname "$0")" && pwd)
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+
+export GIT_AUTHOR_NAME="Fixture Author" GIT_AUTHOR_EMAIL="author@example.com"
+export GIT_COMMITTER_NAME="Fixture Committer" GIT_COMMITTER_EMAIL="committer@example.com"
+export GIT_CONFIG_GLOBAL=/dev/null GIT_CONFIG_NOSYSTEM=1
+at() { export GIT_AUTHOR_DATE="$1 +0000" GIT_COMMITTER_DATE="$1 +0000"; }
+
+cd "$work"
+git init -q -b main .
+seq 1 400 | sed 's/^/line /' > a.txt
+echo "second file" > b.txt
+git add a.txt b.txt
+at "2024-01-01T00:00:00"
+git commit -q -m "Initial commit"
+
+sed -i 's/^line 200$/line 200 changed/' a.txt
+git add a.txt
+at "2024-01-02T00:00:00"
+git commit -q -m "Change line 200"
+
+at "2024-01-03T00:00:00"
+git tag -a v1.0.0 -F - <<'EOF'
+Release 1.0.0
+-----BEGIN PGP SIGNATURE-----
+
+iQEzBAABCAAdFiEEZml4dHVyZSBzaWduYXR1cmUgbm90IHJlYWw=
+=fake
+-----END PGP SIGNATURE-----
+EOF
+
+git gc -q --aggressive --prune=now
+
+echo "line 401" >> a.txt
+git add a.txt
+at "2024-01-04T00:00:00"
+git commit -q -m "Append line 401"
+
+git config core.bare true
+rm -rf .git/hooks .git/logs .git/info .git/index .git/description \
+	.git/COMMIT_EDITMSG .git/ORIG_HEAD .git/objects/info
+rm -rf "$here/fixture.git"
+mv .git "$here/fixture.git"
@@ -0,0 +1,89 @@
+package notary
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// HashAlgorithm selects the payload hash algorithm.
+type HashAlgorithm string
+
+// Supported payload hash algorithms. SHA-256 hashes are written bare for
+// compatibility with existing receipts; the others carry an "alg:" prefix
+// ("sha512:9b71d2...").
+const (
+	HashSHA256 HashAlgorithm = "sha256"
+	HashSHA512 HashAlgorithm = "sha512"
+	HashBLAKE3 HashAlgorithm = "blake3"
+)
+
+// SplitHash returns the algorithm named by a hash's prefix (SHA-256 when
+// unprefixed) and the hex digest.
+func SplitHash(hash string) (HashAlgorithm, string) {
+	if alg, digest, ok := strings.Cut(hash, ":"); ok {
+		return HashAlgorithm(strings.ToLower(alg)), digest
+	}
+	return HashSHA256, hash
+}
+
+// hashBytes hashes data with alg and returns the (prefixed) hash string.
+func hashBytes(alg HashAlgorithm, data []byte) (string, error) {
+	switch alg {
+	case HashSHA256, "":
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:]), nil
+	case HashSHA512:
+		sum := sha512.Sum512(data)
+		return string(alg) + ":" + hex.EncodeToString(sum[:]), nil
+	case HashBLAKE3:
+		sum := blake3Sum256(data)
+		return string(alg) + ":" + hex.EncodeToString(sum[:]), nil
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm %q", alg)
+	}
+}
+
+// VerifyPayloadHash reports whether payload hashes to hash, using the
+// algorithm named by the hash's prefix rather than assuming SHA-256.
+func VerifyPayloadHash(payload map[string]any, hash string) (bool, error) {
+	alg, digest := SplitHash(hash)
+	canonical, err := canonicalPayload(payload)
+	if err != nil {
+		return false, err
+	}
+	computed, err := hashBytes(alg, canonical)
+	if err != nil {
+		return false, err
+	}
+	_, want := SplitHash(computed)
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(digest)), []byte(want)) == 1, nil
+}
+
+// CanonicalPayload returns the bytes ComputeHash hashes: the payload as
+// encoding/json writes it, which is the serialization this SDK has always
+// hashed — sorted keys at every level, compact separators, <, > and &
+// escaped as \u003c, \u003e and \u0026, other non-ASCII text as raw UTF-8,
+// and floats in Go's shortest form ("1", "1e-07", "1e+21"). The testvectors
+// package pins these bytes so a toolchain change that alters them is caught.
+// It fails if the payload cannot be encoded as JSON (NaN or infinite
+// floats, channels).
+func CanonicalPayload(payload map[string]any) ([]byte, error) {
+	return canonicalPayload(payload)
+}
+
+func canonicalPayload(payload map[string]any) ([]byte, error) {
+	if payload == nil {
+		return []byte("{}"), nil
+	}
+	// encoding/json sorts map keys, so nested maps encode deterministically.
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return data, nil
+}
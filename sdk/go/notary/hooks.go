@@ -0,0 +1,55 @@
+package notary
+
+// IssueRequest describes an Issue call, passed to Hooks.OnIssueFailed.
+type IssueRequest struct {
+	ActionType string
+	Payload    map[string]any
+	// Options holds the receipt options the call resolved to.
+	Options IssueOptions
+}
+
+// Hooks are client-level lifecycle callbacks. They fire for every Issue and
+// Verify made through the client, including those from ReceiptQueue
+// consumers and RecordAction, so alerting can live in one place.
+// Hooks run synchronously on the calling goroutine and should be fast.
+//
+//	client, _ := notary.NewClient(key, &notary.Config{Hooks: notary.Hooks{
+//	    OnIssueFailed: func(err error, req notary.IssueRequest) {
+//	        alerts.Send("receipt failed", req.ActionType, err)
+//	    },
+//	}})
+type Hooks struct {
+	// OnIssued is called after a receipt is issued.
+	OnIssued func(receipt *Receipt)
+	// OnIssueFailed is called when issuance fails.
+	OnIssueFailed func(err error, req IssueRequest)
+	// OnVerifyFailed is called when a verification result is invalid or
+	// violates the attached policy.
+	OnVerifyFailed func(result *VerificationResult)
+}
+
+func (c *Client) fireIssued(receipt *Receipt) {
+	if c.hooks.OnIssued != nil {
+		c.hooks.OnIssued(receipt)
+	}
+}
+
+func (c *Client) fireIssueFailed(err error, actionType string, payload map[string]any, co *callOptions) {
+	if c.hooks.OnIssueFailed == nil {
+		return
+	}
+	req := IssueRequest{ActionType: actionType, Payload: payload}
+	if co != nil {
+		req.Options = co.issue
+	}
+	c.hooks.OnIssueFailed(err, req)
+}
+
+func (c *Client) fireVerified(result *VerificationResult) {
+	if c.hooks.OnVerifyFailed == nil || result == nil {
+		return
+	}
+	if !result.Valid || (result.PolicyOK != nil && !*result.PolicyOK) {
+		c.hooks.OnVerifyFailed(result)
+	}
+}
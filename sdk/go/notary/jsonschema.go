@@ -0,0 +1,136 @@
+package notary
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDialect is the JSON Schema draft emitted by SchemaFor.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// SchemaFor returns a JSON Schema describing how T is encoded by
+// encoding/json, so non-Go consumers can validate payloads we store and
+// forward. Fields without omitempty are required; slices, maps and pointers
+// also admit null, as encoding/json writes nil values that way.
+//
+//	schema := notary.SchemaFor[notary.Receipt]()
+//	data, _ := json.MarshalIndent(schema, "", "  ")
+func SchemaFor[T any]() map[string]any {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	schema := typeSchema(t, map[reflect.Type]bool{})
+	schema["$schema"] = JSONSchemaDialect
+	if t.Name() != "" {
+		schema["title"] = t.Name()
+	}
+	return schema
+}
+
+// PublishedSchemas returns the schemas for the SDK's wire types, keyed by
+// type name. cmd/notary-schema writes these to disk.
+func PublishedSchemas() map[string]map[string]any {
+	return map[string]map[string]any{
+		"Receipt":                     SchemaFor[Receipt](),
+		"VerificationResult":          SchemaFor[VerificationResult](),
+		"OfflineVerificationResult":   SchemaFor[OfflineVerificationResult](),
+		"CounterfactualIssueOptions":  SchemaFor[CounterfactualIssueOptions](),
+		"CounterfactualCommitOptions": SchemaFor[CounterfactualCommitOptions](),
+		"CounterfactualItem":          SchemaFor[CounterfactualItem](),
+	}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "description": "nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		inner := typeSchema(t.Elem(), seen)
+		if typ, ok := inner["type"].(string); ok {
+			inner["type"] = []string{typ, "null"}
+		}
+		return inner
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		// Nil slices and maps encode as null, so they are nullable.
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": []string{"string", "null"}, "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": []string{"array", "null"}, "items": typeSchema(t.Elem(), seen)}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := map[string]any{}
+		var required []string
+		collectFields(t, seen, props, &required)
+		schema := map[string]any{
+			"type":       "object",
+			"properties": props,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		// interface{} and anything else: accept any JSON value.
+		return map[string]any{}
+	}
+}
+
+func collectFields(t reflect.Type, seen map[reflect.Type]bool, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectFields(ft, seen, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		props[name] = typeSchema(f.Type, seen)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package notary
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LedgerGenesis is the prev value of the first ledger entry.
+const LedgerGenesis = "GENESIS"
+
+// LedgerEntry is one line of a ledger file.
+type LedgerEntry struct {
+	Seq         int            `json:"seq"`
+	Prev        string         `json:"prev"`
+	RecordedAt  string         `json:"recorded_at"`
+	ReceiptHash string         `json:"receipt_hash"`
+	Receipt     map[string]any `json:"receipt"`
+	Hash        string         `json:"hash"`
+	HMAC        string         `json:"hmac"`
+}
+
+// LedgerIntegrityError reports the first ledger line that failed verification.
+type LedgerIntegrityError struct {
+	Line   int
+	Reason string
+}
+
+func (e *LedgerIntegrityError) Error() string {
+	return fmt.Sprintf("ledger integrity check failed at line %d: %s", e.Line, e.Reason)
+}
+
+// Ledger is a tamper-evident local copy of issued receipts: an append-only
+// JSONL file where every line is hash-linked to the previous one and
+// authenticated with an HMAC, independent of the API.
+//
+//	ledger, err := notary.OpenLedger("receipts.ledger", hmacKey)
+//	client.AttachLedger(ledger)   // every Issue is now appended
+//	err = ledger.Verify()         // re-check the whole file
+//
+// Entries are stamped with time.Now, or with the client clock once the
+// ledger is attached to a Client.
+type Ledger struct {
+	path   string
+	key    []byte
+	mu     sync.Mutex
+	file   *os.File
+	size   int64 // bytes of verified entries; a failed write is cut back to it
+	seq    int
+	head   string
+	hashes map[string]bool
+	now    func() time.Time
+}
+
+// OpenLedger opens (or creates) a ledger file and verifies its existing
+// contents. A *LedgerIntegrityError is returned if the file has been altered.
+// A torn last line, left by a crash mid-write, is truncated rather than
+// reported, since it was never part of the ledger.
+func OpenLedger(path string, key []byte) (*Ledger, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("ledger HMAC key must not be empty")
+	}
+	l := &Ledger{
+		path:   path,
+		key:    append([]byte(nil), key...),
+		head:   LedgerGenesis,
+		hashes: make(map[string]bool),
+		now:    time.Now,
+	}
+
+	size, err := l.scan(true, func(e *LedgerEntry) {
+		l.seq = e.Seq
+		l.head = e.Hash
+		if e.ReceiptHash != "" {
+			l.hashes[e.ReceiptHash] = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.size = size
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// Append records a receipt. Receipts already in the ledger are skipped.
+func (l *Ledger) Append(receipt *Receipt) error {
+	return l.AppendMap(receipt.ReceiptHash, receiptToMap(receipt))
+}
+
+// AppendMap records a raw receipt map under its receipt hash.
+func (l *Ledger) AppendMap(receiptHash string, receipt map[string]any) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("ledger is closed")
+	}
+	if receiptHash != "" && l.hashes[receiptHash] {
+		return nil
+	}
+
+	entry := &LedgerEntry{
+		Seq:         l.seq + 1,
+		Prev:        l.head,
+		RecordedAt:  l.now().UTC().Format(time.RFC3339),
+		ReceiptHash: receiptHash,
+		Receipt:     receipt,
+	}
+	hash, err := ledgerEntryHash(entry)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+	entry.HMAC = l.mac(hash)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode ledger entry: %w", err)
+	}
+	line = append(line, '\n')
+	if _, err := l.file.Write(line); err != nil {
+		return l.rollbackLocked(fmt.Errorf("failed to write ledger entry: %w", err))
+	}
+	if err := l.file.Sync(); err != nil {
+		return l.rollbackLocked(fmt.Errorf("failed to sync ledger: %w", err))
+	}
+
+	l.size += int64(len(line))
+	l.seq = entry.Seq
+	l.head = entry.Hash
+	if receiptHash != "" {
+		l.hashes[receiptHash] = true
+	}
+	return nil
+}
+
+// rollbackLocked cuts off whatever part of a failed append reached the
+// file, so the next append does not land after a fragment.
+func (l *Ledger) rollbackLocked(err error) error {
+	if terr := l.file.Truncate(l.size); terr != nil {
+		return fmt.Errorf("%w; failed to truncate the partial entry: %v", err, terr)
+	}
+	return err
+}
+
+// Import appends receipts that are not yet in the ledger and returns how
+// many were added. Each map must carry a receipt_hash (top level or nested
+// under "receipt", matching Lookup and History responses).
+func (l *Ledger) Import(receipts []map[string]any) (int, error) {
+	added := 0
+	for _, r := range receipts {
+		hash := getString(r, "receipt_hash")
+		body := r
+		if inner, ok := r["receipt"].(map[string]any); ok {
+			body = inner
+			if hash == "" {
+				hash = getString(inner, "receipt_hash")
+			}
+		}
+		if hash == "" {
+			return added, fmt.Errorf("receipt without receipt_hash cannot be imported")
+		}
+		if l.Has(hash) {
+			continue
+		}
+		if err := l.AppendMap(hash, body); err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+// Export returns every receipt in ledger order. Numbers are json.Number, so
+// integers beyond 2^53 survive the round trip.
+func (l *Ledger) Export() ([]map[string]any, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []map[string]any
+	_, err := l.scan(false, func(e *LedgerEntry) {
+		out = append(out, e.Receipt)
+	})
+	return out, err
+}
+
+// Entries returns every ledger entry in order.
+func (l *Ledger) Entries() ([]LedgerEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []LedgerEntry
+	_, err := l.scan(false, func(e *LedgerEntry) {
+		out = append(out, *e)
+	})
+	return out, err
+}
+
+// Verify re-reads the ledger file and checks every line's hash link and HMAC.
+func (l *Ledger) Verify() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	count := 0
+	_, err := l.scan(false, func(*LedgerEntry) { count++ })
+	if err != nil {
+		return err
+	}
+	if count != l.seq {
+		return &LedgerIntegrityError{Line: count, Reason: fmt.Sprintf("expected %d entries, found %d", l.seq, count)}
+	}
+	return nil
+}
+
+// Has reports whether a receipt hash is already in the ledger.
+func (l *Ledger) Has(receiptHash string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.hashes[receiptHash]
+}
+
+// Len returns the number of entries.
+func (l *Ledger) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq
+}
+
+// Head returns the hash of the latest entry (LedgerGenesis if empty).
+func (l *Ledger) Head() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.head
+}
+
+// Sync pages through the server's receipt history and appends any receipts
+// missing from the ledger, fetching full receipts via Lookup. It returns the
+// number of receipts added.
+func (l *Ledger) Sync(client *Client, opts HistoryOptions) (int, error) {
+	if opts.Page == 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize == 0 {
+		opts.PageSize = 100
+	}
+
+	added := 0
+	for {
+		page, err := client.History(opts)
+		if err != nil {
+			return added, err
+		}
+		for _, item := range page.Items {
+			hash := getString(item, "receipt_hash")
+			if hash == "" || l.Has(hash) {
+				continue
+			}
+			found, err := client.Lookup(hash)
+			if err != nil {
+				return added, err
+			}
+			if !found.Found || found.Receipt == nil {
+				continue
+			}
+			if err := l.AppendMap(hash, found.Receipt); err != nil {
+				return added, err
+			}
+			added++
+		}
+		if opts.Page >= page.TotalPages || len(page.Items) == 0 {
+			return added, nil
+		}
+		opts.Page++
+	}
+}
+
+// Close closes the ledger file.
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// scan reads and verifies the file, calling fn for each valid entry, and
+// returns the length of the verified prefix. A final line without a newline
+// is a torn write: with repair set it is truncated, otherwise it is an
+// integrity error.
+func (l *Ledger) scan(repair bool, fn func(*LedgerEntry)) (int64, error) {
+	flag := os.O_RDONLY
+	if repair {
+		flag = os.O_RDWR
+	}
+	f, err := os.OpenFile(l.path, flag, 0)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	prev := LedgerGenesis
+	line := 0
+	var valid int64
+	for {
+		data, readErr := reader.ReadBytes('\n')
+		if len(data) == 0 && readErr != nil {
+			break
+		}
+		line++
+		if readErr != nil {
+			if readErr == io.EOF && repair {
+				if err := f.Truncate(valid); err != nil {
+					return valid, fmt.Errorf("failed to repair ledger: %w", err)
+				}
+				break
+			}
+			return valid, &LedgerIntegrityError{Line: line, Reason: "truncated entry"}
+		}
+		size := int64(len(data))
+		data = bytes.TrimSpace(data)
+
+		// UseNumber keeps integers beyond 2^53 exact, so re-encoding the
+		// receipt for the hash check reproduces what was written.
+		var entry LedgerEntry
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&entry); err != nil {
+			return valid, &LedgerIntegrityError{Line: line, Reason: "malformed entry"}
+		}
+		if entry.Seq != line {
+			return valid, &LedgerIntegrityError{Line: line, Reason: fmt.Sprintf("sequence %d out of order", entry.Seq)}
+		}
+		if entry.Prev != prev {
+			return valid, &LedgerIntegrityError{Line: line, Reason: "broken hash link"}
+		}
+		hash, err := ledgerEntryHash(&entry)
+		if err != nil || hash != entry.Hash {
+			return valid, &LedgerIntegrityError{Line: line, Reason: "entry hash mismatch"}
+		}
+		if !hmac.Equal([]byte(l.mac(hash)), []byte(entry.HMAC)) {
+			return valid, &LedgerIntegrityError{Line: line, Reason: "HMAC mismatch"}
+		}
+
+		fn(&entry)
+		prev = entry.Hash
+		valid += size
+	}
+	return valid, nil
+}
+
+func (l *Ledger) mac(hash string) string {
+	m := hmac.New(sha256.New, l.key)
+	m.Write([]byte(hash))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// ledgerEntryHash hashes everything in an entry except Hash and HMAC.
+// encoding/json sorts map keys, so the receipt encoding is deterministic.
+func ledgerEntryHash(e *LedgerEntry) (string, error) {
+	receiptJSON, err := json.Marshal(e.Receipt)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode receipt: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(e.Seq)))
+	h.Write([]byte{'|'})
+	h.Write([]byte(e.Prev))
+	h.Write([]byte{'|'})
+	h.Write([]byte(e.RecordedAt))
+	h.Write([]byte{'|'})
+	h.Write([]byte(e.ReceiptHash))
+	h.Write([]byte{'|'})
+	h.Write(receiptJSON)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,132 @@
+package notary_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellothere012/notaryos-go/notary"
+	"github.com/hellothere012/notaryos-go/notarytest"
+)
+
+var ledgerKey = []byte("ledger-test-key")
+
+func openTestLedger(t *testing.T, path string) *notary.Ledger {
+	t.Helper()
+	ledger, err := notary.OpenLedger(path, ledgerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ledger.Close() })
+	return ledger
+}
+
+func TestLedgerRepairsTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.ledger")
+	ledger := openTestLedger(t, path)
+	for _, hash := range []string{"sha256:a", "sha256:b"} {
+		if err := ledger.AppendMap(hash, map[string]any{"receipt_hash": hash}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ledger.Close()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":3,"prev":"`)
+	f.Close()
+
+	ledger = openTestLedger(t, path)
+	if n := ledger.Len(); n != 2 {
+		t.Fatalf("Len after repair = %d, want 2", n)
+	}
+	if err := ledger.AppendMap("sha256:c", map[string]any{"receipt_hash": "sha256:c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	ledger.Close()
+	if n := openTestLedger(t, path).Len(); n != 3 {
+		t.Errorf("Len after reopen = %d, want 3", n)
+	}
+}
+
+func TestLedgerDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.ledger")
+	ledger := openTestLedger(t, path)
+	if err := ledger.AppendMap("sha256:a", map[string]any{"amount": 10}); err != nil {
+		t.Fatal(err)
+	}
+	ledger.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), `"amount":10`, `"amount":11`, 1))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = notary.OpenLedger(path, ledgerKey)
+	var integrity *notary.LedgerIntegrityError
+	if !errors.As(err, &integrity) || integrity.Line != 1 {
+		t.Fatalf("OpenLedger error = %v, want an integrity error at line 1", err)
+	}
+}
+
+// TestLedgerLargeIntegers guards against decoding receipt numbers as
+// float64, which rounds integers beyond 2^53 and fails the hash check.
+func TestLedgerLargeIntegers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.ledger")
+	ledger := openTestLedger(t, path)
+	if err := ledger.AppendMap("sha256:a", map[string]any{"sequence": int64(1<<53 + 1)}); err != nil {
+		t.Fatal(err)
+	}
+	ledger.Close()
+
+	receipts, err := openTestLedger(t, path).Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := receipts[0]["sequence"]; got != json.Number("9007199254740993") {
+		t.Errorf("sequence = %v (%T), want 9007199254740993", got, got)
+	}
+}
+
+func TestLedgerUsesClientClock(t *testing.T) {
+	serverNow := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+	srv := notarytest.NewMockServer(&notarytest.MockConfig{Now: func() time.Time { return serverNow }})
+	defer srv.Close()
+	client, err := notary.NewClient("notary_test_key", &notary.Config{BaseURL: srv.URL, TimeSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := client.Issue("billing.charge", map[string]any{"amount": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The mock's Date headers carry real time, so sync last.
+	if _, err := client.SyncTime(); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger := openTestLedger(t, filepath.Join(t.TempDir(), "receipts.ledger"))
+	client.AttachLedger(ledger)
+	if err := ledger.Append(receipt); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ledger.Entries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Entries = %d, %v", len(entries), err)
+	}
+	if !strings.HasPrefix(entries[0].RecordedAt, "2030-06-01T12:00") {
+		t.Errorf("RecordedAt = %s, want the server-synced clock", entries[0].RecordedAt)
+	}
+}
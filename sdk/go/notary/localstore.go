@@ -0,0 +1,466 @@
+package notary
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoredReceipt is a receipt held in a local Store.
+type StoredReceipt struct {
+	ReceiptHash string         `json:"receipt_hash"`
+	ActionType  string         `json:"action_type"`
+	AgentID     string         `json:"agent_id"`
+	Timestamp   time.Time      `json:"timestamp"`
+	Receipt     map[string]any `json:"receipt"`
+}
+
+// LocalQuery selects receipts from a local Store. Zero fields match everything.
+type LocalQuery struct {
+	// ActionType matches exactly, or a namespace when it ends in ".*".
+	ActionType string
+	AgentID    string
+	Since      time.Time
+	Until      time.Time
+	// Limit caps the number of results (0 = no limit).
+	Limit int
+}
+
+func (q LocalQuery) match(r *StoredReceipt) bool {
+	if q.ActionType != "" && !matchesAny(r.ActionType, []string{q.ActionType}) {
+		return false
+	}
+	if q.AgentID != "" && r.AgentID != q.AgentID {
+		return false
+	}
+	if !q.Since.IsZero() && r.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && r.Timestamp.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Store is a local, queryable receipt store used by Syncer. It also persists
+// the sync cursor so syncs resume where they left off.
+type Store interface {
+	// Put adds receipts, ignoring any whose hash is already stored or
+	// was deleted.
+	Put(receipts []StoredReceipt) (added int, err error)
+	// Delete removes receipts by hash, for example once an Archiver has
+	// exported them, and returns how many were stored.
+	Delete(receiptHashes []string) (removed int, err error)
+	// Get returns a receipt by hash.
+	Get(receiptHash string) (*StoredReceipt, bool, error)
+	// Query returns matching receipts ordered by timestamp.
+	Query(q LocalQuery) ([]StoredReceipt, error)
+	// Checkpoint returns the persisted sync cursor ("" if none).
+	Checkpoint() (string, error)
+	// SetCheckpoint persists the sync cursor.
+	SetCheckpoint(cursor string) error
+}
+
+// FileStore is the default Store: an append-only JSONL file plus an
+// in-memory index, with the cursor kept in a sibling file. No database needed.
+// Deletes append tombstone lines, so the file never shrinks.
+type FileStore struct {
+	dir     string
+	mu      sync.RWMutex
+	file    *os.File
+	byHash  map[string]int
+	items   []StoredReceipt // ordered by timestamp
+	deleted map[string]bool
+}
+
+// storeLine is one line of the data file: a receipt, or a tombstone when
+// Deleted is set.
+type storeLine struct {
+	StoredReceipt
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+const (
+	fileStoreData       = "receipts.jsonl"
+	fileStoreCheckpoint = "checkpoint"
+)
+
+// OpenFileStore opens (or creates) a FileStore in dir and loads its index.
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	s := &FileStore{dir: dir, byHash: make(map[string]int), deleted: make(map[string]bool)}
+
+	path := filepath.Join(dir, fileStoreData)
+	if err := s.load(path); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	s.file = f
+	return s, nil
+}
+
+// load reads the data file into the index. A torn final line from a crash
+// is cut off so the next append starts on a fresh line; the receipt is
+// re-synced later because the checkpoint only advances after a full write.
+func (s *FileStore) load(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer f.Close()
+
+	var valid int64 // bytes up to and including the last newline
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			valid += int64(len(line))
+			var r storeLine
+			if json.Unmarshal(line, &r) == nil {
+				if r.Deleted {
+					s.deleted[r.ReceiptHash] = true
+				} else if _, dup := s.byHash[r.ReceiptHash]; !dup {
+					s.byHash[r.ReceiptHash] = 0
+					s.items = append(s.items, r.StoredReceipt)
+				}
+			}
+		}
+		if err == io.EOF {
+			if len(line) > 0 && line[len(line)-1] != '\n' {
+				if err := f.Truncate(valid); err != nil {
+					return fmt.Errorf("failed to repair store: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load store: %w", err)
+		}
+	}
+	if len(s.deleted) > 0 {
+		s.dropDeleted()
+	}
+	s.reindex()
+	return nil
+}
+
+// Put appends receipts not already stored.
+func (s *FileStore) Put(receipts []StoredReceipt) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf []byte
+	var fresh []StoredReceipt
+	seen := make(map[string]bool)
+	for _, r := range receipts {
+		if r.ReceiptHash == "" || seen[r.ReceiptHash] || s.deleted[r.ReceiptHash] {
+			continue
+		}
+		if _, dup := s.byHash[r.ReceiptHash]; dup {
+			continue
+		}
+		line, err := json.Marshal(r)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode receipt: %w", err)
+		}
+		buf = append(append(buf, line...), '\n')
+		fresh = append(fresh, r)
+		seen[r.ReceiptHash] = true
+	}
+	if len(fresh) == 0 {
+		return 0, nil
+	}
+	if s.file == nil {
+		return 0, fmt.Errorf("store is closed")
+	}
+
+	if _, err := s.file.Write(buf); err != nil {
+		return 0, fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync store: %w", err)
+	}
+	s.merge(fresh)
+	return len(fresh), nil
+}
+
+// merge inserts fresh into the time-ordered items, shifting only the tail
+// that sorts after the batch, and updates the hash index for moved items.
+func (s *FileStore) merge(fresh []StoredReceipt) {
+	sort.SliceStable(fresh, func(i, j int) bool { return fresh[i].Timestamp.Before(fresh[j].Timestamp) })
+	first := sort.Search(len(s.items), func(i int) bool { return s.items[i].Timestamp.After(fresh[0].Timestamp) })
+
+	i, j := len(s.items)-1, len(fresh)-1
+	s.items = append(s.items, fresh...)
+	for k := len(s.items) - 1; j >= 0; k-- {
+		if i >= first && s.items[i].Timestamp.After(fresh[j].Timestamp) {
+			s.items[k] = s.items[i]
+			i--
+		} else {
+			s.items[k] = fresh[j]
+			j--
+		}
+	}
+	for k := first; k < len(s.items); k++ {
+		s.byHash[s.items[k].ReceiptHash] = k
+	}
+}
+
+// Delete appends a tombstone for each stored hash and drops it from the
+// index. Deleted hashes stay excluded from later Puts.
+func (s *FileStore) Delete(receiptHashes []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf []byte
+	var gone []string
+	for _, h := range receiptHashes {
+		if _, ok := s.byHash[h]; !ok || s.deleted[h] {
+			continue
+		}
+		line, err := json.Marshal(storeLine{StoredReceipt: StoredReceipt{ReceiptHash: h}, Deleted: true})
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode tombstone: %w", err)
+		}
+		buf = append(append(buf, line...), '\n')
+		gone = append(gone, h)
+	}
+	if len(gone) == 0 {
+		return 0, nil
+	}
+	if s.file == nil {
+		return 0, fmt.Errorf("store is closed")
+	}
+
+	if _, err := s.file.Write(buf); err != nil {
+		return 0, fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync store: %w", err)
+	}
+	for _, h := range gone {
+		s.deleted[h] = true
+		delete(s.byHash, h)
+	}
+	s.dropDeleted()
+	for i, r := range s.items {
+		s.byHash[r.ReceiptHash] = i
+	}
+	return len(gone), nil
+}
+
+// dropDeleted removes tombstoned receipts from items, keeping their order.
+func (s *FileStore) dropDeleted() {
+	kept := s.items[:0]
+	for _, r := range s.items {
+		if s.deleted[r.ReceiptHash] {
+			delete(s.byHash, r.ReceiptHash)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	s.items = kept
+}
+
+// reindex sorts items by time and rebuilds the hash index.
+func (s *FileStore) reindex() {
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].Timestamp.Before(s.items[j].Timestamp) })
+	for i, r := range s.items {
+		s.byHash[r.ReceiptHash] = i
+	}
+}
+
+// Get returns a receipt by hash.
+func (s *FileStore) Get(receiptHash string) (*StoredReceipt, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i, ok := s.byHash[receiptHash]
+	if !ok {
+		return nil, false, nil
+	}
+	r := s.items[i]
+	return &r, true, nil
+}
+
+// Query returns matching receipts ordered by timestamp.
+func (s *FileStore) Query(q LocalQuery) ([]StoredReceipt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := 0
+	if !q.Since.IsZero() {
+		start = sort.Search(len(s.items), func(i int) bool { return !s.items[i].Timestamp.Before(q.Since) })
+	}
+	var out []StoredReceipt
+	for i := start; i < len(s.items); i++ {
+		r := &s.items[i]
+		if !q.Until.IsZero() && r.Timestamp.After(q.Until) {
+			break
+		}
+		if q.match(r) {
+			out = append(out, *r)
+			if q.Limit > 0 && len(out) >= q.Limit {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// Len returns the number of stored receipts.
+func (s *FileStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items)
+}
+
+// Checkpoint returns the persisted sync cursor.
+func (s *FileStore) Checkpoint() (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, fileStoreCheckpoint))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SetCheckpoint atomically persists the sync cursor.
+func (s *FileStore) SetCheckpoint(cursor string) error {
+	tmp := filepath.Join(s.dir, fileStoreCheckpoint+".tmp")
+	if err := os.WriteFile(tmp, []byte(cursor+"\n"), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, fileStoreCheckpoint))
+}
+
+// Close closes the data file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// SyncResult summarizes one Syncer.Sync run.
+type SyncResult struct {
+	Fetched int
+	Added   int
+	Cursor  string
+}
+
+// Syncer incrementally downloads receipts into a local Store so they can be
+// queried without hitting the API.
+//
+//	store, _ := notary.OpenFileStore("./receipts")
+//	syncer := notary.NewSyncer(client, store)
+//	result, err := syncer.Sync()
+//	refunds, _ := store.Query(notary.LocalQuery{ActionType: "billing.*", Since: lastWeek})
+type Syncer struct {
+	client *Client
+	store  Store
+	// PageSize is the History page size (default 100).
+	PageSize int
+	// ClerkToken is passed to History when set.
+	ClerkToken string
+}
+
+// NewSyncer creates a Syncer writing into store.
+func NewSyncer(client *Client, store Store) *Syncer {
+	return &Syncer{client: client, store: store, PageSize: 100}
+}
+
+// Store returns the underlying local store for queries.
+func (s *Syncer) Store() Store {
+	return s.store
+}
+
+// Sync fetches receipts newer than the stored checkpoint, stores them, and
+// advances the checkpoint to the newest timestamp seen. Receipts at the
+// checkpoint boundary are fetched again and de-duplicated by hash.
+func (s *Syncer) Sync() (*SyncResult, error) {
+	cursor, err := s.store.Checkpoint()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	result := &SyncResult{Cursor: cursor}
+
+	latest, _ := time.Parse(time.RFC3339, cursor)
+	opts := HistoryOptions{Page: 1, PageSize: s.PageSize, StartDate: cursor, ClerkToken: s.ClerkToken}
+	for {
+		page, err := s.client.History(opts)
+		if err != nil {
+			return result, err
+		}
+		batch := make([]StoredReceipt, 0, len(page.Items))
+		for _, item := range page.Items {
+			r := storedReceiptFromItem(item)
+			if r.ReceiptHash == "" {
+				continue
+			}
+			if r.Timestamp.After(latest) {
+				latest = r.Timestamp
+			}
+			batch = append(batch, r)
+		}
+		result.Fetched += len(batch)
+		added, err := s.store.Put(batch)
+		result.Added += added
+		if err != nil {
+			return result, err
+		}
+		if opts.Page >= page.TotalPages || len(page.Items) == 0 {
+			break
+		}
+		opts.Page++
+	}
+
+	// Only advance the cursor once every page is stored.
+	if !latest.IsZero() {
+		result.Cursor = latest.UTC().Format(time.RFC3339)
+		if err := s.store.SetCheckpoint(result.Cursor); err != nil {
+			return result, fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func storedReceiptFromItem(item map[string]any) StoredReceipt {
+	body := item
+	if inner, ok := item["receipt"].(map[string]any); ok {
+		body = inner
+	}
+	hash := getString(item, "receipt_hash")
+	if hash == "" {
+		hash = getString(body, "receipt_hash")
+	}
+	ts, _ := time.Parse(time.RFC3339, getString(body, "timestamp"))
+	return StoredReceipt{
+		ReceiptHash: hash,
+		ActionType:  getString(body, "action_type"),
+		AgentID:     getString(body, "agent_id"),
+		Timestamp:   ts,
+		Receipt:     body,
+	}
+}
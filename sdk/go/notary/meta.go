@@ -0,0 +1,81 @@
+package notary
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseMeta holds transport-level metadata captured from an API response.
+// It is attached to every NotaryError that came from an HTTP response and is
+// returned alongside results by the *WithMeta call variants.
+//
+//	receipt, meta, err := client.IssueWithMeta("my_action", payload)
+//	fmt.Println(meta.RequestID, meta.RateLimitRemaining)
+type ResponseMeta struct {
+	// Status is the HTTP status code of the response.
+	Status int
+	// RequestID is the server-assigned X-Request-Id (quote it in support tickets).
+	RequestID string
+	// RateLimitLimit is X-RateLimit-Limit, or -1 if absent.
+	RateLimitLimit int
+	// RateLimitRemaining is X-RateLimit-Remaining, or -1 if absent.
+	RateLimitRemaining int
+	// RateLimitReset is when the current rate-limit window resets (zero if absent).
+	RateLimitReset time.Time
+	// RetryAfter is the server-requested backoff from Retry-After (zero if absent).
+	RetryAfter time.Duration
+	// ServerTiming is the raw Server-Timing header.
+	ServerTiming string
+	// Header is the full response header set.
+	Header http.Header
+}
+
+// HasRateLimit reports whether the response carried rate-limit headers.
+func (m *ResponseMeta) HasRateLimit() bool {
+	return m != nil && m.RateLimitRemaining >= 0
+}
+
+func newResponseMeta(resp *http.Response) *ResponseMeta {
+	h := resp.Header
+	meta := &ResponseMeta{
+		Status:             resp.StatusCode,
+		RequestID:          h.Get("X-Request-Id"),
+		RateLimitLimit:     headerInt(h, "X-RateLimit-Limit"),
+		RateLimitRemaining: headerInt(h, "X-RateLimit-Remaining"),
+		ServerTiming:       h.Get("Server-Timing"),
+		Header:             h.Clone(),
+	}
+
+	// X-RateLimit-Reset is either epoch seconds or seconds-until-reset.
+	if reset := headerInt(h, "X-RateLimit-Reset"); reset >= 0 {
+		if reset > 1e9 {
+			meta.RateLimitReset = time.Unix(int64(reset), 0)
+		} else {
+			meta.RateLimitReset = time.Now().Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	// Retry-After is either delta-seconds or an HTTP date.
+	if ra := h.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			meta.RetryAfter = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(ra); err == nil {
+			meta.RetryAfter = time.Until(t)
+		}
+	}
+
+	return meta
+}
+
+func headerInt(h http.Header, key string) int {
+	v := h.Get(key)
+	if v == "" {
+		return -1
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return -1
+	}
+	return n
+}
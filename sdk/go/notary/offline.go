@@ -4,10 +4,14 @@ import (
 	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"time"
 )
 
 // OfflineVerificationResult holds the result of offline verification.
@@ -16,24 +20,67 @@ type OfflineVerificationResult struct {
 	SignatureOK bool   `json:"signature_ok"`
 	StructureOK bool   `json:"structure_ok"`
 	Reason      string `json:"reason"`
-	KeyID       string `json:"key_id"`
+	// ReasonCode is a stable ReasonXxx code for invalid results; Reason is
+	// the human-readable detail and may name keys and timestamps.
+	ReasonCode string `json:"reason_code,omitempty"`
+	KeyID      string `json:"key_id"`
+	KeyStatus  string `json:"key_status,omitempty"`
+	// PolicyOK and Violations are set when a PolicyEngine is attached.
+	PolicyOK   *bool             `json:"policy_ok,omitempty"`
+	Violations []PolicyViolation `json:"policy_violations,omitempty"`
 }
 
 // OfflineVerifier verifies Notary receipt signatures using cached Ed25519 public keys.
 // It fetches keys from JWKS and performs all verification locally.
 type OfflineVerifier struct {
-	keys map[string]ed25519.PublicKey // kid -> 32-byte public key
+	keys   map[string]ed25519.PublicKey // kid -> 32-byte public key
+	meta   map[string]KeyInfo           // kid -> status and lifecycle timestamps
+	policy *PolicyEngine
+	// Now returns the current time for policy checks and VerifyDetached
+	// (default time.Now; Client.OfflineVerifier sets the client clock).
+	Now func() time.Time
+}
+
+// Key status values from the JWKS "status" field.
+const (
+	KeyStatusActive  = "active"
+	KeyStatusRetired = "retired"
+	KeyStatusRevoked = "revoked"
+)
+
+// Offline verification failure codes reported in ReasonCode.
+const (
+	ReasonMissingFields     = "missing_fields"
+	ReasonUnknownKey        = "unknown_key"
+	ReasonKeyRevoked        = "key_revoked"
+	ReasonKeyRetired        = "key_retired"
+	ReasonKeyUntrusted      = "key_untrusted"
+	ReasonMalformedSig      = "malformed_signature"
+	ReasonSignatureMismatch = "signature_mismatch"
+)
+
+// KeyInfo is the audit metadata for a cached verification key.
+type KeyInfo struct {
+	KeyID     string    `json:"kid"`
+	Alg       string    `json:"alg"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	RetiredAt time.Time `json:"retired_at"`
+	RevokedAt time.Time `json:"revoked_at"`
 }
 
 // JWK represents a JSON Web Key (Ed25519 OKP).
 type jwkKey struct {
-	Kty    string `json:"kty"`
-	Crv    string `json:"crv"`
-	Alg    string `json:"alg"`
-	Use    string `json:"use"`
-	Kid    string `json:"kid"`
-	X      string `json:"x"`
-	Status string `json:"status,omitempty"`
+	Kty       string `json:"kty"`
+	Crv       string `json:"crv"`
+	Alg       string `json:"alg"`
+	Use       string `json:"use"`
+	Kid       string `json:"kid"`
+	X         string `json:"x"`
+	Status    string `json:"status,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	RetiredAt string `json:"retired_at,omitempty"`
+	RevokedAt string `json:"revoked_at,omitempty"`
 }
 
 type jwksResponse struct {
@@ -49,10 +96,44 @@ func NewOfflineVerifier(baseURL string) (*OfflineVerifier, error) {
 	if baseURL == "" {
 		baseURL = DefaultBaseURL
 	}
+	return loadOfflineVerifier(&keyFetcher{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	})
+}
+
+// OfflineVerifier creates an OfflineVerifier from the client's server,
+// fetching keys through the client's transport, headers and timeout. The
+// verifier uses the client clock (see Client.Now).
+func (c *Client) OfflineVerifier() (*OfflineVerifier, error) {
+	v, err := loadOfflineVerifier(&keyFetcher{baseURL: c.baseURL, httpClient: c.httpClient, setHeaders: c.setHeaders})
+	if err != nil {
+		return nil, err
+	}
+	v.Now = c.Now
+	return v, nil
+}
+
+// keyFetcher performs the key endpoint requests for loadOfflineVerifier.
+type keyFetcher struct {
+	baseURL    string
+	httpClient *http.Client
+	setHeaders func(*http.Request)
+}
 
-	url := strings.TrimRight(baseURL, "/") + "/.well-known/jwks.json"
+func (f *keyFetcher) get(path string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", f.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.setHeaders != nil {
+		f.setHeaders(req)
+	}
+	return f.httpClient.Do(req)
+}
 
-	resp, err := http.Get(url)
+func loadOfflineVerifier(f *keyFetcher) (*OfflineVerifier, error) {
+	resp, err := f.get("/.well-known/jwks.json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
 	}
@@ -73,6 +154,7 @@ func NewOfflineVerifier(baseURL string) (*OfflineVerifier, error) {
 	}
 
 	keys := make(map[string]ed25519.PublicKey)
+	meta := make(map[string]KeyInfo)
 	for _, jwk := range jwks.Keys {
 		if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" {
 			continue
@@ -91,20 +173,148 @@ func NewOfflineVerifier(baseURL string) (*OfflineVerifier, error) {
 			}
 		}
 
-		if len(rawKey) == ed25519.PublicKeySize {
-			keys[jwk.Kid] = ed25519.PublicKey(rawKey)
+		if len(rawKey) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[jwk.Kid] = ed25519.PublicKey(rawKey)
+
+		info := KeyInfo{
+			KeyID:     jwk.Kid,
+			Alg:       jwk.Alg,
+			Status:    strings.ToLower(jwk.Status),
+			CreatedAt: parseKeyTime(jwk.CreatedAt),
+			RetiredAt: parseKeyTime(jwk.RetiredAt),
+			RevokedAt: parseKeyTime(jwk.RevokedAt),
+		}
+		if info.Status == "" {
+			info.Status = KeyStatusActive
+		}
+		// Retired keys need their retirement time; fetch it if JWKS omits it.
+		if info.Status == KeyStatusRetired && info.RetiredAt.IsZero() {
+			if fetched, err := f.keyInfo(jwk.Kid); err == nil {
+				info.RetiredAt = fetched.RetiredAt
+				if info.CreatedAt.IsZero() {
+					info.CreatedAt = fetched.CreatedAt
+				}
+			}
 		}
+		meta[jwk.Kid] = info
 	}
 
 	if len(keys) == 0 {
 		return nil, fmt.Errorf("no Ed25519 keys found in JWKS response")
 	}
 
-	return &OfflineVerifier{keys: keys}, nil
+	return &OfflineVerifier{keys: keys, meta: meta}, nil
+}
+
+// keyInfo reads lifecycle metadata for one key from the key endpoint.
+func (f *keyFetcher) keyInfo(kid string) (*KeyInfo, error) {
+	resp, err := f.get("/v1/notary/keys/" + url.PathEscape(kid))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("key metadata fetch failed with status %d", resp.StatusCode)
+	}
+
+	var raw jwkKey
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return &KeyInfo{
+		KeyID:     kid,
+		Status:    strings.ToLower(raw.Status),
+		CreatedAt: parseKeyTime(raw.CreatedAt),
+		RetiredAt: parseKeyTime(raw.RetiredAt),
+		RevokedAt: parseKeyTime(raw.RevokedAt),
+	}, nil
+}
+
+func parseKeyTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+// checkKeyStatus reports why a key may not verify a signature made at
+// signedAt, or "" if it may. Only active keys verify unconditionally; retired
+// keys verify signatures made before their retirement (a zero signedAt is
+// unknown and refused), and any other status (revoked, compromised, or one
+// this SDK does not know) is refused.
+func (v *OfflineVerifier) checkKeyStatus(kid string, signedAt time.Time) (status, reason string) {
+	info, ok := v.meta[kid]
+	if !ok {
+		return "", ""
+	}
+	switch info.Status {
+	case "", KeyStatusActive:
+	case KeyStatusRevoked:
+		return info.Status, fmt.Sprintf("Key %s has been revoked", kid)
+	case KeyStatusRetired:
+		if info.RetiredAt.IsZero() {
+			return info.Status, fmt.Sprintf("Key %s is retired and its retirement time is unknown", kid)
+		}
+		if signedAt.IsZero() {
+			return info.Status, "Signing time is missing or unparseable; cannot check retired key"
+		}
+		if !signedAt.Before(info.RetiredAt) {
+			return info.Status, fmt.Sprintf("Key %s was retired at %s, before this signature was made", kid, info.RetiredAt.Format(time.RFC3339))
+		}
+	default:
+		return info.Status, fmt.Sprintf("Key %s has status %q and is not trusted", kid, info.Status)
+	}
+	return info.Status, ""
+}
+
+// keyStatusCode maps a refused key's status to its ReasonCode.
+func keyStatusCode(status string) string {
+	switch status {
+	case KeyStatusRevoked:
+		return ReasonKeyRevoked
+	case KeyStatusRetired:
+		return ReasonKeyRetired
+	default:
+		return ReasonKeyUntrusted
+	}
+}
+
+// SetPolicy runs a PolicyEngine on every Verify, reporting compliance in
+// PolicyOK and Violations. Valid is not affected. Pass nil to detach.
+func (v *OfflineVerifier) SetPolicy(policy *PolicyEngine) {
+	v.policy = policy
 }
 
 // Verify checks a receipt's signature offline using cached Ed25519 keys.
 func (v *OfflineVerifier) Verify(receipt map[string]any) *OfflineVerificationResult {
+	result := v.verifySignature(receipt)
+	v.applyPolicy(result, receipt)
+	return result
+}
+
+func (v *OfflineVerifier) applyPolicy(result *OfflineVerificationResult, receipt map[string]any) {
+	if v.policy != nil {
+		if v.Now != nil {
+			result.Violations = v.policy.evaluateAt(receipt, v.Now())
+		} else {
+			result.Violations = v.policy.EvaluateMap(receipt)
+		}
+		policyOK := len(result.Violations) == 0
+		result.PolicyOK = &policyOK
+	}
+}
+
+// verifyScratch holds buffers reused across verifications by one worker.
+type verifyScratch struct {
+	msg []byte
+	sig []byte
+}
+
+func (v *OfflineVerifier) verifySignature(receipt map[string]any) *OfflineVerificationResult {
+	return v.verifySignatureScratch(receipt, &verifyScratch{})
+}
+
+func (v *OfflineVerifier) verifySignatureScratch(receipt map[string]any, scratch *verifyScratch) *OfflineVerificationResult {
 	// Check structure
 	required := []string{
 		"receipt_id", "timestamp", "agent_id", "action_type",
@@ -123,6 +333,7 @@ func (v *OfflineVerifier) Verify(receipt map[string]any) *OfflineVerificationRes
 			SignatureOK: false,
 			StructureOK: false,
 			Reason:      fmt.Sprintf("Missing required fields: %s", strings.Join(missing, ", ")),
+			ReasonCode:  ReasonMissingFields,
 		}
 	}
 
@@ -153,36 +364,58 @@ func (v *OfflineVerifier) Verify(receipt map[string]any) *OfflineVerificationRes
 			SignatureOK: false,
 			StructureOK: true,
 			Reason:      fmt.Sprintf("Unknown key ID: %s", kid),
+			ReasonCode:  ReasonUnknownKey,
+			KeyID:       kid,
+		}
+	}
+
+	issued, _ := time.Parse(time.RFC3339, getString(receipt, "timestamp"))
+	keyStatus, statusReason := v.checkKeyStatus(kid, issued)
+	if statusReason != "" {
+		return &OfflineVerificationResult{
+			Valid:       false,
+			SignatureOK: false,
+			StructureOK: true,
+			Reason:      statusReason,
+			ReasonCode:  keyStatusCode(keyStatus),
 			KeyID:       kid,
+			KeyStatus:   keyStatus,
 		}
 	}
 
-	// Reconstruct canonical message
-	canonical := buildCanonical(receipt)
+	// Reconstruct canonical message (v1 pipe-joined or v2 canonical JSON)
+	version := CanonicalVersion(receipt)
+	if version == CanonicalV2 {
+		scratch.msg = append(scratch.msg[:0], buildCanonicalV2(receipt)...)
+	} else {
+		scratch.msg = appendCanonicalV1(scratch.msg[:0], receipt)
+	}
 
 	// Decode signature
 	sigStr := getString(receipt, "signature")
-	sigBytes, err := base64.StdEncoding.DecodeString(sigStr)
+	sigBytes, err := decodeSignature(scratch, sigStr, base64.StdEncoding)
 	if err != nil {
 		// Try URL-safe base64
-		sigBytes, err = base64.RawURLEncoding.DecodeString(sigStr)
+		sigBytes, err = decodeSignature(scratch, sigStr, base64.RawURLEncoding)
 		if err != nil {
 			return &OfflineVerificationResult{
 				Valid:       false,
 				SignatureOK: false,
 				StructureOK: true,
 				Reason:      fmt.Sprintf("Failed to decode signature: %v", err),
+				ReasonCode:  ReasonMalformedSig,
 				KeyID:       kid,
+				KeyStatus:   keyStatus,
 			}
 		}
 	}
 
-	// Verify Ed25519 signature
-	valid := ed25519.Verify(pubKey, []byte(canonical), sigBytes)
+	// Verify Ed25519 (v1) or Ed25519ph (v2) signature
+	valid := verifyCanonical(version, pubKey, scratch.msg, sigBytes)
 
-	reason := "Signature verified locally"
+	reason, code := "Signature verified locally", ""
 	if !valid {
-		reason = "Signature mismatch"
+		reason, code = "Signature mismatch", ReasonSignatureMismatch
 	}
 
 	return &OfflineVerificationResult{
@@ -190,7 +423,9 @@ func (v *OfflineVerifier) Verify(receipt map[string]any) *OfflineVerificationRes
 		SignatureOK: valid,
 		StructureOK: true,
 		Reason:      reason,
+		ReasonCode:  code,
 		KeyID:       kid,
+		KeyStatus:   keyStatus,
 	}
 }
 
@@ -203,13 +438,68 @@ func (v *OfflineVerifier) KeyIDs() []string {
 	return ids
 }
 
-func buildCanonical(receipt map[string]any) string {
+// Keys returns the status and lifecycle metadata of every cached key,
+// sorted by key ID, for audit display.
+func (v *OfflineVerifier) Keys() []KeyInfo {
+	infos := make([]KeyInfo, 0, len(v.meta))
+	for _, info := range v.meta {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].KeyID < infos[j].KeyID })
+	return infos
+}
+
+// VerifyDetached checks a detached signature over an already-canonicalized
+// message with the cached key kid. alg selects Ed25519 or Ed25519ph. The
+// signature is taken to be made now, so retired keys are refused; use
+// VerifyDetachedAt for signatures with a known signing time.
+func (v *OfflineVerifier) VerifyDetached(message []byte, signature, kid, alg string) (bool, error) {
+	return v.VerifyDetachedAt(message, signature, kid, alg, v.now())
+}
+
+func (v *OfflineVerifier) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
+
+// VerifyDetachedAt is VerifyDetached for a signature made at signedAt, which
+// a retired key may verify if it predates the retirement.
+func (v *OfflineVerifier) VerifyDetachedAt(message []byte, signature, kid, alg string, signedAt time.Time) (bool, error) {
+	pubKey, ok := v.keys[kid]
+	if !ok {
+		return false, fmt.Errorf("unknown key ID: %s", kid)
+	}
+	if _, reason := v.checkKeyStatus(kid, signedAt); reason != "" {
+		return false, errors.New(reason)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		sig, err = base64.RawURLEncoding.DecodeString(signature)
+		if err != nil {
+			return false, fmt.Errorf("failed to decode signature: %w", err)
+		}
+	}
+	version := CanonicalV1
+	if strings.EqualFold(alg, AlgEd25519ph) {
+		version = CanonicalV2
+	}
+	return verifyCanonical(version, pubKey, message, sig), nil
+}
+
+func buildCanonicalV1(receipt map[string]any) string {
+	return string(appendCanonicalV1(nil, receipt))
+}
+
+// appendCanonicalV1 appends the pipe-joined v1 message to buf.
+func appendCanonicalV1(buf []byte, receipt map[string]any) []byte {
 	prevHash := getString(receipt, "previous_receipt_hash")
 	if prevHash == "" {
 		prevHash = "GENESIS"
 	}
 
-	parts := []string{
+	parts := [...]string{
 		getString(receipt, "receipt_id"),
 		getString(receipt, "timestamp"),
 		getString(receipt, "agent_id"),
@@ -218,7 +508,25 @@ func buildCanonical(receipt map[string]any) string {
 		getString(receipt, "payload_hash"),
 		prevHash,
 	}
-	return strings.Join(parts, "|")
+	for i, p := range parts {
+		if i > 0 {
+			buf = append(buf, '|')
+		}
+		buf = append(buf, p...)
+	}
+	return buf
+}
+
+// decodeSignature decodes sig into the scratch buffer.
+func decodeSignature(scratch *verifyScratch, sig string, enc *base64.Encoding) ([]byte, error) {
+	if n := enc.DecodedLen(len(sig)); cap(scratch.sig) < n {
+		scratch.sig = make([]byte, n)
+	}
+	n, err := enc.Decode(scratch.sig[:cap(scratch.sig)], []byte(sig))
+	if err != nil {
+		return nil, err
+	}
+	return scratch.sig[:n], nil
 }
 
 func getString(m map[string]any, key string) string {
@@ -0,0 +1,145 @@
+package notary
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// BatchResult is one receipt's outcome from VerifyAll or VerifyStream.
+type BatchResult struct {
+	// Index is the receipt's position in the input.
+	Index     int
+	ReceiptID string
+	Result    *OfflineVerificationResult
+}
+
+// BatchStats aggregates a batch verification.
+type BatchStats struct {
+	Total   int
+	Valid   int
+	Invalid int
+	// ByReason counts invalid receipts per ReasonCode.
+	ByReason map[string]int
+	// ReasonSamples holds the first Reason text seen for each ReasonCode.
+	ReasonSamples map[string]string
+	// PolicyFailures counts receipts that violate the attached policy.
+	PolicyFailures int
+	Duration       time.Duration
+}
+
+// BatchVerification is a running batch verification. Results stream in
+// completion order; Wait returns the aggregate once every receipt is done.
+//
+//	batch := verifier.VerifyAll(receipts, 16)
+//	for r := range batch.Results() {
+//	    if !r.Result.Valid {
+//	        log.Printf("%s: %s", r.ReceiptID, r.Result.Reason)
+//	    }
+//	}
+//	stats := batch.Wait()
+type BatchVerification struct {
+	results chan BatchResult
+	done    chan struct{}
+	stats   BatchStats
+}
+
+// Results streams per-receipt results. The channel is closed when the
+// batch completes. Results must be drained (or Wait called) for the batch
+// to make progress.
+func (b *BatchVerification) Results() <-chan BatchResult {
+	return b.results
+}
+
+// Wait discards any unread results, blocks until the batch completes, and
+// returns the aggregate statistics.
+func (b *BatchVerification) Wait() *BatchStats {
+	for range b.results {
+	}
+	<-b.done
+	return &b.stats
+}
+
+// VerifyAll verifies receipts with concurrency workers (GOMAXPROCS when
+// <= 0). Keys are shared from the verifier's cache and each worker reuses
+// its buffers, so throughput scales with cores.
+func (v *OfflineVerifier) VerifyAll(receipts []map[string]any, concurrency int) *BatchVerification {
+	in := make(chan map[string]any, 256)
+	go func() {
+		defer close(in)
+		for _, r := range receipts {
+			in <- r
+		}
+	}()
+	return v.VerifyStream(in, concurrency)
+}
+
+// VerifyStream is VerifyAll for receipts read from a channel, so sets too
+// large for memory can be streamed from disk. The batch completes when in
+// is closed.
+func (v *OfflineVerifier) VerifyStream(in <-chan map[string]any, concurrency int) *BatchVerification {
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	b := &BatchVerification{
+		results: make(chan BatchResult, concurrency*4),
+		done:    make(chan struct{}),
+		stats:   BatchStats{ByReason: make(map[string]int), ReasonSamples: make(map[string]string)},
+	}
+
+	type job struct {
+		index   int
+		receipt map[string]any
+	}
+	jobs := make(chan job, concurrency*4)
+	start := time.Now()
+
+	go func() {
+		defer close(jobs)
+		i := 0
+		for r := range in {
+			jobs <- job{index: i, receipt: r}
+			i++
+		}
+	}()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scratch := &verifyScratch{}
+			for j := range jobs {
+				result := v.verifySignatureScratch(j.receipt, scratch)
+				v.applyPolicy(result, j.receipt)
+
+				mu.Lock()
+				b.stats.Total++
+				if result.Valid {
+					b.stats.Valid++
+				} else {
+					b.stats.Invalid++
+					b.stats.ByReason[result.ReasonCode]++
+					if _, ok := b.stats.ReasonSamples[result.ReasonCode]; !ok {
+						b.stats.ReasonSamples[result.ReasonCode] = result.Reason
+					}
+				}
+				if result.PolicyOK != nil && !*result.PolicyOK {
+					b.stats.PolicyFailures++
+				}
+				mu.Unlock()
+
+				b.results <- BatchResult{Index: j.index, ReceiptID: getString(j.receipt, "receipt_id"), Result: result}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		b.stats.Duration = time.Since(start)
+		close(b.done)
+		close(b.results)
+	}()
+	return b
+}
@@ -0,0 +1,204 @@
+package notary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Per-call options. Each call accepts its own option type, so an option
+// that cannot apply to a call is a compile error rather than silently
+// ignored. The CallOptions (WithContext, WithTimeout, WithHeader,
+// WithIdempotencyKey) are accepted by every call:
+//
+//	receipt, err := client.Issue("billing.refund", payload,
+//	    notary.WithPreviousHash(prev),
+//	    notary.WithIdempotencyKey(refundID),
+//	    notary.WithTimeout(5*time.Second),
+//	)
+type (
+	// IssueOption configures an Issue call.
+	IssueOption interface{ applyIssue(*callOptions) }
+	// VerifyOption configures a Verify call.
+	VerifyOption interface{ applyVerify(*callOptions) }
+	// LookupOption configures a Lookup call.
+	LookupOption interface{ applyLookup(*callOptions) }
+	// HistoryOption configures a History or Find call.
+	HistoryOption interface{ applyHistory(*callOptions) }
+)
+
+// CallOption is an option accepted by every call.
+type CallOption func(*callOptions)
+
+func (f CallOption) applyIssue(o *callOptions)   { f(o) }
+func (f CallOption) applyVerify(o *callOptions)  { f(o) }
+func (f CallOption) applyLookup(o *callOptions)  { f(o) }
+func (f CallOption) applyHistory(o *callOptions) { f(o) }
+
+type issueOption func(*callOptions)
+
+func (f issueOption) applyIssue(o *callOptions) { f(o) }
+
+// callOptions is the resolved set of options for one call.
+type callOptions struct {
+	issue          IssueOptions
+	history        HistoryOptions
+	ctx            context.Context
+	timeout        time.Duration
+	headers        map[string]string
+	idempotencyKey string
+}
+
+// newCallOptions resolves opts with apply, a method expression such as
+// IssueOption.applyIssue. Nil options are skipped.
+func newCallOptions[T any](opts []T, apply func(T, *callOptions)) *callOptions {
+	o := &callOptions{}
+	for _, opt := range opts {
+		if any(opt) != nil {
+			apply(opt, o)
+		}
+	}
+	return o
+}
+
+// WithPreviousHash chains an issued receipt to a previous receipt hash.
+func WithPreviousHash(hash string) IssueOption {
+	return issueOption(func(o *callOptions) { o.issue.PreviousReceiptHash = hash })
+}
+
+// WithMetadata attaches opaque metadata to an issued receipt.
+func WithMetadata(metadata map[string]any) IssueOption {
+	return issueOption(func(o *callOptions) { o.issue.Metadata = metadata })
+}
+
+// WithHashAlgorithm asks the server to hash the payload with alg.
+func WithHashAlgorithm(alg HashAlgorithm) IssueOption {
+	return issueOption(func(o *callOptions) { o.issue.HashAlgorithm = alg })
+}
+
+// WithTags adds server-indexed tags to an issued receipt.
+func WithTags(tags ...string) IssueOption {
+	return issueOption(func(o *callOptions) { o.issue.Tags = append(o.issue.Tags, tags...) })
+}
+
+// WithIndexedField adds a server-indexed field to an issued receipt.
+func WithIndexedField(name, value string) IssueOption {
+	return issueOption(func(o *callOptions) {
+		if o.issue.IndexedFields == nil {
+			o.issue.IndexedFields = make(map[string]string)
+		}
+		o.issue.IndexedFields[name] = value
+	})
+}
+
+// WithContext makes ctx bound the call: cancelling it aborts the request and
+// any retry wait. WithTimeout, if also given, applies on top of ctx.
+func WithContext(ctx context.Context) CallOption {
+	return func(o *callOptions) { o.ctx = ctx }
+}
+
+// WithTimeout bounds the whole call, retries included. The client's
+// Config.Timeout still caps each individual attempt.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) { o.timeout = d }
+}
+
+// WithHeader sends an extra header on the call. Like Config.Headers, it
+// cannot override the authentication headers.
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string)
+		}
+		o.headers[http.CanonicalHeaderKey(key)] = value
+	}
+}
+
+// WithIdempotencyKey sends an Idempotency-Key header, so a retried Issue
+// (by the SDK or by the caller) returns the original receipt instead of
+// issuing a duplicate.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) { o.idempotencyKey = key }
+}
+
+// applyIssue lets IssueOptions be passed where an IssueOption is expected,
+// so calls written against the old variadic signature keep compiling. Its
+// non-zero fields override earlier options.
+func (opts IssueOptions) applyIssue(o *callOptions) {
+	if opts.PreviousReceiptHash != "" {
+		o.issue.PreviousReceiptHash = opts.PreviousReceiptHash
+	}
+	if opts.Metadata != nil {
+		o.issue.Metadata = opts.Metadata
+	}
+	if opts.HashAlgorithm != "" {
+		o.issue.HashAlgorithm = opts.HashAlgorithm
+	}
+	if len(opts.Tags) > 0 {
+		o.issue.Tags = append(o.issue.Tags, opts.Tags...)
+	}
+	for name, value := range opts.IndexedFields {
+		WithIndexedField(name, value).applyIssue(o)
+	}
+}
+
+// applyHistory makes HistoryOptions the query of a History or Find call.
+func (opts HistoryOptions) applyHistory(o *callOptions) {
+	o.history = opts
+}
+
+// context returns the context bounding the call.
+func (o *callOptions) context() (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if o != nil && o.ctx != nil {
+		ctx = o.ctx
+	}
+	if o == nil || o.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// setHeaders applies the per-call headers. Call it before the
+// authentication headers are set.
+func (o *callOptions) setHeaders(req *http.Request) {
+	if o == nil {
+		return
+	}
+	for k, v := range o.headers {
+		req.Header.Set(k, v)
+	}
+	if o.idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", o.idempotencyKey)
+	}
+}
+
+// contextError reports a call ended by its context: ERR_TIMEOUT when the
+// deadline passed, ERR_CANCELED when the caller cancelled it.
+func contextError(ctx context.Context, cause error, meta *ResponseMeta) *NotaryError {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return &NotaryError{Message: fmt.Sprintf("request canceled: %v", cause), Code: "ERR_CANCELED", Meta: meta}
+	}
+	return &NotaryError{Message: fmt.Sprintf("request timed out: %v", cause), Code: "ERR_TIMEOUT", Meta: meta}
+}
+
+// connectionError reports a failed round trip, or the context error when
+// the call's context caused it.
+func connectionError(ctx context.Context, err error) *NotaryError {
+	if ctx.Err() != nil {
+		return contextError(ctx, err, nil)
+	}
+	return &NotaryError{Message: fmt.Sprintf("connection failed: %v", err), Code: "ERR_CONNECTION"}
+}
+
+// sleepContext waits for d, returning early if ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
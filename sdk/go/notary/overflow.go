@@ -0,0 +1,222 @@
+package notary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// QueueItem is a receipt job that did not fit in a ReceiptQueue.
+type QueueItem struct {
+	ActionType string         `json:"action_type"`
+	Payload    map[string]any `json:"payload"`
+	PrevHash   string         `json:"prev_hash,omitempty"`
+	Priority   Priority       `json:"priority,omitempty"`
+	EnqueuedAt time.Time      `json:"enqueued_at"`
+}
+
+// OverflowSink receives queue items when a ReceiptQueue is full, so no
+// receipt request is lost. Implement it to write to Kafka, SQS, etc.;
+// SpoolSink provides a local-directory implementation.
+type OverflowSink interface {
+	Write(item QueueItem) error
+}
+
+// SpooledItem is an item held by a ReplaySource, identified for Ack.
+type SpooledItem struct {
+	ID   string
+	Item QueueItem
+}
+
+// ReplaySource hands spooled items back for replay.
+type ReplaySource interface {
+	// Pending returns up to n items, oldest first, without removing them.
+	Pending(n int) ([]SpooledItem, error)
+	// Ack removes an item once it has been re-queued.
+	Ack(id string) error
+}
+
+// SpoolSink spools overflow items to a local directory, one JSON file per
+// item. It implements both OverflowSink and ReplaySource.
+//
+//	spool, err := notary.NewSpoolSink("/var/spool/notary")
+//	queue.SetOverflowSink(spool)
+//	replayer := notary.NewReplayer(spool, queue, 10*time.Second)
+//	replayer.Start()
+//	defer replayer.Stop()
+type SpoolSink struct {
+	dir string
+	seq uint64
+}
+
+const spoolExt = ".json"
+
+// NewSpoolSink creates a spool in dir, creating the directory if needed.
+func NewSpoolSink(dir string) (*SpoolSink, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	return &SpoolSink{dir: dir}, nil
+}
+
+// Write spools an item. The file is written under a temporary name and
+// renamed so readers never see partial items.
+func (s *SpoolSink) Write(item QueueItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode spool item: %w", err)
+	}
+
+	// Zero-padded nanos + sequence sorts lexically in arrival order.
+	name := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), atomic.AddUint64(&s.seq, 1))
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write spool item: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name+spoolExt)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to commit spool item: %w", err)
+	}
+	return nil
+}
+
+// Pending returns up to n spooled items, oldest first (n <= 0 means all).
+func (s *SpoolSink) Pending(n int) ([]SpooledItem, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), spoolExt) && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	if n > 0 && len(names) > n {
+		names = names[:n]
+	}
+
+	items := make([]SpooledItem, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return items, fmt.Errorf("failed to read spool item %s: %w", name, err)
+		}
+		var item QueueItem
+		if err := json.Unmarshal(data, &item); err != nil {
+			return items, fmt.Errorf("corrupt spool item %s: %w", name, err)
+		}
+		items = append(items, SpooledItem{ID: name, Item: item})
+	}
+	return items, nil
+}
+
+// Ack deletes a spooled item.
+func (s *SpoolSink) Ack(id string) error {
+	if id != filepath.Base(id) {
+		return fmt.Errorf("invalid spool item id: %s", id)
+	}
+	err := os.Remove(filepath.Join(s.dir, id))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Replayer feeds spooled items back into a ReceiptQueue as capacity returns.
+type Replayer struct {
+	source   ReplaySource
+	queue    *ReceiptQueue
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	mu       sync.Mutex
+	replayed int
+	lastErr  error
+}
+
+// NewReplayer creates a replayer that polls source every interval (default 5s).
+func NewReplayer(source ReplaySource, queue *ReceiptQueue, interval time.Duration) *Replayer {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &Replayer{
+		source:   source,
+		queue:    queue,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// ReplayOnce moves as many spooled items into the queue as currently fit and
+// returns how many were replayed. Items are acked only after being queued.
+func (r *Replayer) ReplayOnce() (int, error) {
+	free := r.queue.Free()
+	if free <= 0 {
+		return 0, nil
+	}
+	items, err := r.source.Pending(free)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, s := range items {
+		if !r.queue.TryEnqueuePriority(s.Item.Priority, s.Item.ActionType, s.Item.Payload) {
+			break
+		}
+		count++
+		if err := r.source.Ack(s.ID); err != nil {
+			return count, fmt.Errorf("replayed item %s but failed to ack: %w", s.ID, err)
+		}
+	}
+	return count, nil
+}
+
+// Start begins background replay.
+func (r *Replayer) Start() {
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				n, err := r.ReplayOnce()
+				r.mu.Lock()
+				r.replayed += n
+				r.lastErr = err
+				r.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stop halts background replay and waits for the current pass to finish.
+// It must only be called after Start.
+func (r *Replayer) Stop() {
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+}
+
+// Stats returns the number of items replayed in the background and the
+// error from the most recent pass, if any.
+func (r *Replayer) Stats() (replayed int, lastErr error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.replayed, r.lastErr
+}
@@ -0,0 +1,183 @@
+package notary
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PolicyViolation describes one rule a receipt failed.
+type PolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyRule is a compliance check evaluated against a receipt map.
+// It returns nil when the receipt complies.
+type PolicyRule interface {
+	Name() string
+	Check(receipt map[string]any, now time.Time) *PolicyViolation
+}
+
+// PolicyRuleFunc adapts a function to a PolicyRule.
+type PolicyRuleFunc struct {
+	RuleName string
+	Fn       func(receipt map[string]any, now time.Time) string
+}
+
+// Name returns the rule name.
+func (f PolicyRuleFunc) Name() string { return f.RuleName }
+
+// Check returns a violation when Fn returns a non-empty message.
+func (f PolicyRuleFunc) Check(receipt map[string]any, now time.Time) *PolicyViolation {
+	if msg := f.Fn(receipt, now); msg != "" {
+		return &PolicyViolation{Rule: f.RuleName, Message: msg}
+	}
+	return nil
+}
+
+// PolicyEngine evaluates receipts against registered compliance rules.
+// Attach it to a Client or OfflineVerifier to run it on every verification.
+//
+//	policy := notary.NewPolicyEngine(
+//	    notary.RequireChained("billing.*"),
+//	    notary.MaxAge(24*time.Hour),
+//	    notary.RequireMetadata("ticket_id"),
+//	    notary.AllowAgents("billing-agent", "ledger-agent"),
+//	)
+//	violations := policy.Evaluate(receipt)
+type PolicyEngine struct {
+	mu    sync.RWMutex
+	rules []PolicyRule
+	// Now returns the time Evaluate checks against (default time.Now).
+	// Engines attached to a Client, or to an OfflineVerifier with a clock,
+	// use that clock instead.
+	Now func() time.Time
+}
+
+// NewPolicyEngine creates an engine with the given rules.
+func NewPolicyEngine(rules ...PolicyRule) *PolicyEngine {
+	return &PolicyEngine{rules: append([]PolicyRule(nil), rules...)}
+}
+
+// Add registers additional rules.
+func (p *PolicyEngine) Add(rules ...PolicyRule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, rules...)
+}
+
+// Evaluate checks a receipt against every rule and returns all violations.
+func (p *PolicyEngine) Evaluate(receipt *Receipt) []PolicyViolation {
+	return p.EvaluateMap(receiptToMap(receipt))
+}
+
+// EvaluateMap is Evaluate for a raw receipt map.
+func (p *PolicyEngine) EvaluateMap(receipt map[string]any) []PolicyViolation {
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	return p.evaluateAt(receipt, now())
+}
+
+// evaluateAt evaluates with an explicit current time.
+func (p *PolicyEngine) evaluateAt(receipt map[string]any, now time.Time) []PolicyViolation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var violations []PolicyViolation
+	for _, rule := range p.rules {
+		if v := rule.Check(receipt, now); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+	return violations
+}
+
+// RequireChained requires receipts whose action_type matches any pattern to
+// carry a previous_receipt_hash. Patterns ending in ".*" match a namespace.
+func RequireChained(actionPatterns ...string) PolicyRule {
+	return PolicyRuleFunc{RuleName: "require_chained", Fn: func(r map[string]any, _ time.Time) string {
+		action := getString(r, "action_type")
+		if !matchesAny(action, actionPatterns) {
+			return ""
+		}
+		prev := getString(r, "previous_receipt_hash")
+		if prev == "" || prev == "GENESIS" {
+			return fmt.Sprintf("action %s must be chained to a previous receipt", action)
+		}
+		return ""
+	}}
+}
+
+// MaxAge rejects receipts whose timestamp is older than maxAge.
+func MaxAge(maxAge time.Duration) PolicyRule {
+	return PolicyRuleFunc{RuleName: "max_age", Fn: func(r map[string]any, now time.Time) string {
+		ts, err := time.Parse(time.RFC3339, getString(r, "timestamp"))
+		if err != nil {
+			return "receipt timestamp is missing or unparseable"
+		}
+		if age := now.Sub(ts); age > maxAge {
+			return fmt.Sprintf("receipt is %s old (max %s)", age.Truncate(time.Second), maxAge)
+		}
+		return ""
+	}}
+}
+
+// RequireMetadata requires the receipt's metadata to contain every key.
+func RequireMetadata(keys ...string) PolicyRule {
+	return PolicyRuleFunc{RuleName: "require_metadata", Fn: func(r map[string]any, _ time.Time) string {
+		meta, _ := r["metadata"].(map[string]any)
+		var missing []string
+		for _, k := range keys {
+			if v, ok := meta[k]; !ok || v == nil || v == "" {
+				missing = append(missing, k)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Sprintf("missing metadata keys: %s", strings.Join(missing, ", "))
+		}
+		return ""
+	}}
+}
+
+// AllowAgents restricts receipts to the listed agent IDs.
+func AllowAgents(agentIDs ...string) PolicyRule {
+	allowed := make(map[string]bool, len(agentIDs))
+	for _, id := range agentIDs {
+		allowed[id] = true
+	}
+	return PolicyRuleFunc{RuleName: "allow_agents", Fn: func(r map[string]any, _ time.Time) string {
+		agent := getString(r, "agent_id")
+		if !allowed[agent] {
+			return fmt.Sprintf("agent %q is not allowed", agent)
+		}
+		return ""
+	}}
+}
+
+// matchesAny reports whether s equals a pattern or falls under a "ns.*" pattern.
+func matchesAny(s string, patterns []string) bool {
+	for _, p := range patterns {
+		if p == "*" || p == s {
+			return true
+		}
+		if strings.HasSuffix(p, ".*") && strings.HasPrefix(s, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// receiptToMap returns the raw receipt map, re-encoding the struct if needed.
+func receiptToMap(receipt *Receipt) map[string]any {
+	if receipt.Raw != nil {
+		return receipt.Raw
+	}
+	var m map[string]any
+	data, _ := json.Marshal(receipt)
+	_ = json.Unmarshal(data, &m)
+	return m
+}
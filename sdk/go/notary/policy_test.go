@@ -0,0 +1,68 @@
+package notary_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hellothere012/notaryos-go/notary"
+	"github.com/hellothere012/notaryos-go/notarytest"
+)
+
+func TestPolicyEngineNow(t *testing.T) {
+	issued := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	receipt := map[string]any{"timestamp": issued.Format(time.RFC3339)}
+	policy := notary.NewPolicyEngine(notary.MaxAge(time.Hour))
+
+	policy.Now = func() time.Time { return issued.Add(30 * time.Minute) }
+	if v := policy.EvaluateMap(receipt); len(v) != 0 {
+		t.Errorf("30 minutes after issue: %v", v)
+	}
+	policy.Now = func() time.Time { return issued.Add(2 * time.Hour) }
+	if v := policy.EvaluateMap(receipt); len(v) != 1 || v[0].Rule != "max_age" {
+		t.Errorf("2 hours after issue: %v, want a max_age violation", v)
+	}
+}
+
+// TestOfflineVerifierUsesClientClock runs a MaxAge policy against a server
+// whose clock is years behind this host: only a verifier on the synced
+// client clock sees the receipt as fresh.
+func TestOfflineVerifierUsesClientClock(t *testing.T) {
+	serverNow := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := notarytest.NewMockServer(&notarytest.MockConfig{Now: func() time.Time { return serverNow }})
+	defer srv.Close()
+	client, err := notary.NewClient("notary_test_key", &notary.Config{BaseURL: srv.URL, TimeSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := client.Issue("billing.charge", map[string]any{"amount": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	synced, err := client.OfflineVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := notary.NewOfflineVerifier(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The mock's Date headers carry real time, so sync last.
+	if _, err := client.SyncTime(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		verifier *notary.OfflineVerifier
+		policyOK bool
+	}{
+		{"client clock", synced, true},
+		{"local clock", local, false},
+	} {
+		tc.verifier.SetPolicy(notary.NewPolicyEngine(notary.MaxAge(time.Hour)))
+		result := tc.verifier.Verify(receipt.Raw)
+		if !result.Valid || result.PolicyOK == nil || *result.PolicyOK != tc.policyOK {
+			t.Errorf("%s: valid %v, policy %v, want policy %v (%v)", tc.name, result.Valid, result.PolicyOK, tc.policyOK, result.Violations)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package notary
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Header names used to propagate provenance across service boundaries.
+// gRPC metadata uses the lowercase forms.
+const (
+	HeaderReceiptHash = "X-Notary-Receipt-Hash"
+	HeaderChainHead   = "X-Notary-Chain-Head"
+)
+
+// Provenance is the receipt context carried between services.
+type Provenance struct {
+	// ReceiptHash is the upstream receipt that caused the current work.
+	ReceiptHash string
+	// ChainHead is the latest receipt in the upstream agent's chain.
+	ChainHead string
+}
+
+type provenanceKey struct{}
+
+// WithProvenance returns a context carrying p.
+func WithProvenance(ctx context.Context, p Provenance) context.Context {
+	return context.WithValue(ctx, provenanceKey{}, p)
+}
+
+// ContextWithReceipt returns a context whose provenance points at receipt,
+// so outbound calls continue from it.
+func ContextWithReceipt(ctx context.Context, receipt *Receipt) context.Context {
+	return WithProvenance(ctx, Provenance{ReceiptHash: receipt.ReceiptHash, ChainHead: receipt.ReceiptHash})
+}
+
+// ProvenanceFromContext returns the provenance carried by ctx, if any.
+func ProvenanceFromContext(ctx context.Context) (Provenance, bool) {
+	p, ok := ctx.Value(provenanceKey{}).(Provenance)
+	return p, ok && (p.ReceiptHash != "" || p.ChainHead != "")
+}
+
+// InjectHTTP copies the provenance in ctx onto an outbound request's headers.
+//
+//	req, _ := http.NewRequestWithContext(ctx, "POST", url, body)
+//	notary.InjectHTTP(ctx, req)
+func InjectHTTP(ctx context.Context, req *http.Request) {
+	p, ok := ProvenanceFromContext(ctx)
+	if !ok {
+		return
+	}
+	if p.ReceiptHash != "" {
+		req.Header.Set(HeaderReceiptHash, p.ReceiptHash)
+	}
+	if p.ChainHead != "" {
+		req.Header.Set(HeaderChainHead, p.ChainHead)
+	}
+}
+
+// ExtractHTTP returns the request's context with any inbound provenance
+// headers attached.
+//
+//	ctx := notary.ExtractHTTP(r)
+//	receipt, err := client.IssueContext(ctx, "order.fulfil", payload)
+func ExtractHTTP(r *http.Request) context.Context {
+	p := Provenance{
+		ReceiptHash: r.Header.Get(HeaderReceiptHash),
+		ChainHead:   r.Header.Get(HeaderChainHead),
+	}
+	if p.ReceiptHash == "" && p.ChainHead == "" {
+		return r.Context()
+	}
+	return WithProvenance(r.Context(), p)
+}
+
+// ProvenanceMiddleware attaches inbound provenance headers to each request's context.
+func ProvenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ExtractHTTP(r)))
+	})
+}
+
+// InjectMetadata copies the provenance in ctx into gRPC-style metadata.
+// metadata.MD from google.golang.org/grpc can be passed directly:
+//
+//	md := metadata.MD{}
+//	notary.InjectMetadata(ctx, md)
+//	ctx = metadata.NewOutgoingContext(ctx, md)
+func InjectMetadata(ctx context.Context, md map[string][]string) {
+	p, ok := ProvenanceFromContext(ctx)
+	if !ok {
+		return
+	}
+	if p.ReceiptHash != "" {
+		md[strings.ToLower(HeaderReceiptHash)] = []string{p.ReceiptHash}
+	}
+	if p.ChainHead != "" {
+		md[strings.ToLower(HeaderChainHead)] = []string{p.ChainHead}
+	}
+}
+
+// ExtractMetadata returns ctx with provenance read from gRPC-style metadata.
+//
+//	md, _ := metadata.FromIncomingContext(ctx)
+//	ctx = notary.ExtractMetadata(ctx, md)
+func ExtractMetadata(ctx context.Context, md map[string][]string) context.Context {
+	first := func(key string) string {
+		if v := md[strings.ToLower(key)]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	p := Provenance{
+		ReceiptHash: first(HeaderReceiptHash),
+		ChainHead:   first(HeaderChainHead),
+	}
+	if p.ReceiptHash == "" && p.ChainHead == "" {
+		return ctx
+	}
+	return WithProvenance(ctx, p)
+}
+
+// IssueContext is Issue that links the new receipt to the upstream receipt
+// carried in ctx by adding it to metadata.provenance_refs, so Provenance
+// reports can follow the DAG across services. ctx also bounds the call, as
+// with WithContext; a WithContext among opts takes precedence.
+func (c *Client) IssueContext(ctx context.Context, actionType string, payload map[string]any, opts ...IssueOption) (*Receipt, error) {
+	all := make([]IssueOption, 0, len(opts)+2)
+	all = append(all, WithContext(ctx))
+	all = append(all, opts...)
+
+	p, ok := ProvenanceFromContext(ctx)
+	if !ok {
+		return c.Issue(actionType, payload, all...)
+	}
+
+	existing := newCallOptions(opts, IssueOption.applyIssue).issue.Metadata
+	metadata := make(map[string]any, len(existing)+1)
+	for k, v := range existing {
+		metadata[k] = v
+	}
+	upstream := p.ReceiptHash
+	if upstream == "" {
+		upstream = p.ChainHead
+	}
+	// Build a new slice so the caller's provenance_refs is never written.
+	var refs []any
+	switch existing := metadata["provenance_refs"].(type) {
+	case []any:
+		refs = append(make([]any, 0, len(existing)+1), existing...)
+	case []string:
+		refs = make([]any, 0, len(existing)+1)
+		for _, r := range existing {
+			refs = append(refs, r)
+		}
+	}
+	metadata["provenance_refs"] = append(refs, upstream)
+
+	return c.Issue(actionType, payload, append(all, WithMetadata(metadata))...)
+}
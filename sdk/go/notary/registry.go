@@ -0,0 +1,194 @@
+package notary
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ActionRegistry is the set of action types a client may issue. Issue and
+// RecordAction check it locally, so typos ("user_delte") never reach the
+// audit trail.
+//
+// Entries are exact action types ("billing.refund") or namespaces
+// ("billing.*", matching every action type under billing.).
+//
+//	registry := notary.NewActionRegistry("user_delete", "billing.*")
+//	client.SetActionRegistry(registry)
+type ActionRegistry struct {
+	mu         sync.RWMutex
+	exact      map[string]bool
+	namespaces map[string]bool
+	permissive bool
+}
+
+// NewActionRegistry creates a registry holding actionTypes. It panics on an
+// invalid entry, like regexp.MustCompile; use Register for runtime input.
+func NewActionRegistry(actionTypes ...string) *ActionRegistry {
+	r := &ActionRegistry{exact: make(map[string]bool), namespaces: make(map[string]bool)}
+	if err := r.Register(actionTypes...); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Register adds action types or "ns.*" namespaces.
+func (r *ActionRegistry) Register(actionTypes ...string) error {
+	for _, t := range actionTypes {
+		if err := checkActionPattern(t); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range actionTypes {
+		if ns, ok := strings.CutSuffix(t, ".*"); ok {
+			r.namespaces[ns] = true
+		} else {
+			r.exact[t] = true
+		}
+	}
+	return nil
+}
+
+func checkActionPattern(t string) error {
+	name := strings.TrimSuffix(t, ".*")
+	if name == "" || strings.ContainsAny(name, " \t\r\n*") {
+		return fmt.Errorf("invalid action type %q", t)
+	}
+	for _, seg := range strings.Split(name, ".") {
+		if seg == "" {
+			return fmt.Errorf("invalid action type %q: empty namespace segment", t)
+		}
+	}
+	return nil
+}
+
+// SetPermissive switches between rejecting unregistered action types
+// (the default) and only logging a warning through the client's Logger.
+func (r *ActionRegistry) SetPermissive(permissive bool) {
+	r.mu.Lock()
+	r.permissive = permissive
+	r.mu.Unlock()
+}
+
+// Permissive reports whether unregistered action types only warn.
+func (r *ActionRegistry) Permissive() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.permissive
+}
+
+// Allowed reports whether actionType is registered, exactly or through
+// one of its enclosing namespaces.
+func (r *ActionRegistry) Allowed(actionType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.exact[actionType] {
+		return true
+	}
+	for ns := actionType; ; {
+		i := strings.LastIndexByte(ns, '.')
+		if i < 0 {
+			return false
+		}
+		ns = ns[:i]
+		if r.namespaces[ns] {
+			return true
+		}
+	}
+}
+
+// Validate returns an ERR_UNKNOWN_ACTION_TYPE error for an unregistered
+// action type, suggesting the closest registered one.
+func (r *ActionRegistry) Validate(actionType string) error {
+	if r.Allowed(actionType) {
+		return nil
+	}
+	msg := fmt.Sprintf("action type %q is not registered", actionType)
+	details := map[string]any{"action_type": actionType}
+	if s := r.suggest(actionType); s != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", s)
+		details["suggestion"] = s
+	}
+	return &NotaryError{Message: msg, Code: "ERR_UNKNOWN_ACTION_TYPE", Details: details}
+}
+
+// Registered returns the registered entries, sorted, namespaces as "ns.*".
+func (r *ActionRegistry) Registered() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.exact)+len(r.namespaces))
+	for t := range r.exact {
+		out = append(out, t)
+	}
+	for ns := range r.namespaces {
+		out = append(out, ns+".*")
+	}
+	sort.Strings(out)
+	return out
+}
+
+// suggest returns the registered action type closest to t, if it is
+// within a typo's distance.
+func (r *ActionRegistry) suggest(t string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	best, bestDist := "", 3
+	for candidate := range r.exact {
+		if d := editDistance(t, candidate); d < bestDist || (d == bestDist && best != "" && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// SetActionRegistry validates every Issue and RecordAction against
+// registry. Pass nil to detach.
+func (c *Client) SetActionRegistry(registry *ActionRegistry) {
+	c.registry = registry
+}
+
+// checkAction validates actionType against the attached registry. In
+// permissive mode violations are logged and nil is returned.
+func (c *Client) checkAction(actionType string) error {
+	if c == nil || c.registry == nil {
+		return nil
+	}
+	err := c.registry.Validate(actionType)
+	if err != nil && c.registry.Permissive() {
+		c.logf("[NotaryOS] warning: %v", err)
+		return nil
+	}
+	return err
+}
+
+func (c *Client) logf(format string, args ...any) {
+	if c.logger != nil {
+		c.logger.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,185 @@
+package notary
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoundTripperConfig configures ReceiptingRoundTripper.
+type RoundTripperConfig struct {
+	// ActionType of the issued receipts (default "http.outbound").
+	ActionType string
+	// Mode: "all" (default) or "errors_only" (transport errors and 4xx/5xx).
+	Mode string
+	// PathTemplate maps a request to the path recorded in the receipt
+	// (default: DefaultPathTemplate).
+	PathTemplate func(req *http.Request) string
+	// Skip excludes requests from receipting. Calls to the queue client's
+	// own API are always skipped.
+	Skip func(req *http.Request) bool
+	// Priority is the queue lane for these receipts (default PriorityNormal).
+	Priority Priority
+}
+
+var pathIDSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{16,}|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// DefaultPathTemplate replaces numeric, UUID and long hex path segments with
+// "{id}", so receipts group by endpoint instead of by resource:
+// /v1/users/42/orders/9f8c... becomes /v1/users/{id}/orders/{id}.
+func DefaultPathTemplate(req *http.Request) string {
+	segments := strings.Split(req.URL.EscapedPath(), "/")
+	for i, seg := range segments {
+		if unescaped, err := url.PathUnescape(seg); err == nil && pathIDSegment.MatchString(unescaped) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// ReceiptingRoundTripper wraps rt (http.DefaultTransport if nil) so every
+// outbound call enqueues a receipt on queue with the host, method, path
+// template, status, duration, and a SHA-256 of the request body. Agents
+// notarize their external side effects without touching call sites:
+//
+//	httpClient := &http.Client{
+//	    Transport: notary.ReceiptingRoundTripper(nil, queue, nil),
+//	}
+func ReceiptingRoundTripper(rt http.RoundTripper, queue *ReceiptQueue, cfg *RoundTripperConfig) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if cfg == nil {
+		cfg = &RoundTripperConfig{}
+	}
+	c := *cfg
+	if c.ActionType == "" {
+		c.ActionType = "http.outbound"
+	}
+	if c.Mode == "" {
+		c.Mode = "all"
+	}
+	if c.PathTemplate == nil {
+		c.PathTemplate = DefaultPathTemplate
+	}
+
+	var notaryHost string
+	if queue != nil && queue.client != nil {
+		if u, err := url.Parse(queue.client.baseURL); err == nil {
+			notaryHost = u.Host
+		}
+	}
+	return &receiptingTransport{next: rt, queue: queue, cfg: c, notaryHost: notaryHost}
+}
+
+type receiptingTransport struct {
+	next       http.RoundTripper
+	queue      *ReceiptQueue
+	cfg        RoundTripperConfig
+	notaryHost string
+}
+
+func (t *receiptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.queue == nil || req.URL.Host == t.notaryHost || (t.cfg.Skip != nil && t.cfg.Skip(req)) {
+		return t.next.RoundTrip(req)
+	}
+
+	// Hash the body from a fresh copy when possible; otherwise hash it as
+	// the transport sends it. The caller's request is never modified.
+	var bodyHash string
+	var tee *hashingReader
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				h := sha256.New()
+				_, err := io.Copy(h, body)
+				body.Close()
+				if err == nil {
+					bodyHash = hex.EncodeToString(h.Sum(nil))
+				}
+			}
+		}
+		if bodyHash == "" {
+			tee = &hashingReader{body: req.Body, h: sha256.New()}
+			req = req.Clone(req.Context())
+			req.Body = tee
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	failed := err != nil || status >= 400
+	if t.cfg.Mode == "errors_only" && !failed {
+		return resp, err
+	}
+
+	if tee != nil {
+		bodyHash = tee.sum()
+	}
+	payload := map[string]any{
+		"host":        req.URL.Host,
+		"method":      req.Method,
+		"path":        t.cfg.PathTemplate(req),
+		"status":      status,
+		"duration_ms": float64(duration.Microseconds()) / 1000,
+	}
+	if bodyHash != "" {
+		payload["request_body_sha256"] = bodyHash
+	} else if tee != nil {
+		// The transport returned before sending the whole body.
+		payload["request_body_partial"] = true
+	}
+	if err != nil {
+		payload["error"] = safeRepr(err.Error())
+	}
+	t.queue.EnqueuePriority(t.cfg.Priority, t.cfg.ActionType, payload)
+
+	return resp, err
+}
+
+// hashingReader hashes a request body as the transport reads it. The
+// transport may still be reading when RoundTrip returns, hence the lock.
+type hashingReader struct {
+	body io.ReadCloser
+	mu   sync.Mutex
+	h    hash.Hash
+	eof  bool
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.mu.Lock()
+	r.h.Write(p[:n])
+	if err == io.EOF {
+		r.eof = true
+	}
+	r.mu.Unlock()
+	return n, err
+}
+
+func (r *hashingReader) Close() error {
+	return r.body.Close()
+}
+
+// sum returns the body hash, or "" if the body has not been fully read.
+func (r *hashingReader) sum() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.eof {
+		return ""
+	}
+	return hex.EncodeToString(r.h.Sum(nil))
+}
@@ -0,0 +1,245 @@
+package notary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MaxReceiptBytes bounds the input accepted by ParseReceipt.
+const MaxReceiptBytes = 1 << 20
+
+// DefaultSchemaVersion is assumed for receipts without schema_version.
+const DefaultSchemaVersion = "1.0"
+
+// SchemaRules are the validation rules for one major schema version.
+type SchemaRules struct {
+	// Required fields must be present and non-empty.
+	Required []string
+	// Known fields are understood by this SDK; anything else is additive.
+	Known []string
+	// Validate runs extra checks after field validation (optional).
+	Validate func(receipt map[string]any) error
+}
+
+var (
+	schemaMu       sync.RWMutex
+	schemaRegistry = map[int]SchemaRules{}
+)
+
+var v1Fields = []string{
+	"receipt_id", "timestamp", "agent_id", "action_type",
+	"payload_hash", "signature", "signature_type",
+}
+
+func init() {
+	known := append([]string{
+		"key_id", "kid", "alg", "schema_version", "chain_sequence",
+		"previous_receipt_hash", "receipt_hash", "verify_url", "metadata",
+		"receipt_type", "crit", "tags", "indexed_fields",
+	}, v1Fields...)
+
+	RegisterSchema(1, SchemaRules{Required: v1Fields, Known: known})
+	RegisterSchema(2, SchemaRules{
+		Required: v1Fields,
+		Known:    known,
+		Validate: func(r map[string]any) error {
+			if getString(r, "kid") == "" && getString(r, "key_id") == "" {
+				return fmt.Errorf("schema 2 receipts require kid or key_id")
+			}
+			return nil
+		},
+	})
+}
+
+// RegisterSchema installs (or replaces) the rules for a major schema version.
+func RegisterSchema(major int, rules SchemaRules) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	schemaRegistry[major] = rules
+}
+
+// SupportedSchemaMajors lists the registered major versions.
+func SupportedSchemaMajors() []int {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	majors := make([]int, 0, len(schemaRegistry))
+	for m := range schemaRegistry {
+		majors = append(majors, m)
+	}
+	sort.Ints(majors)
+	return majors
+}
+
+// ParseOptions controls ParseReceipt.
+type ParseOptions struct {
+	// Strict rejects duplicate keys and any field named in the receipt's
+	// "crit" list that this SDK does not know. Without Strict, unknown
+	// fields are tolerated as additive minor-version changes.
+	Strict bool
+}
+
+// ParseReceipt decodes and validates a receipt, selecting validation rules
+// by its schema_version. Unknown major versions are rejected with
+// ERR_UNSUPPORTED_SCHEMA; newer minor versions of a known major are accepted.
+//
+//	receipt, err := notary.ParseReceipt(data, &notary.ParseOptions{Strict: true})
+func ParseReceipt(data []byte, opts *ParseOptions) (*Receipt, error) {
+	if len(data) > MaxReceiptBytes {
+		return nil, &NotaryError{Message: fmt.Sprintf("receipt exceeds %d bytes", MaxReceiptBytes), Code: ErrPayloadTooLarge}
+	}
+	strict := opts != nil && opts.Strict
+
+	if strict {
+		if err := checkDuplicateKeys(data); err != nil {
+			return nil, &NotaryError{Message: err.Error(), Code: ErrInvalidStructure}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, &NotaryError{Message: fmt.Sprintf("malformed receipt JSON: %v", err), Code: ErrInvalidStructure}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &NotaryError{Message: "trailing data after receipt JSON", Code: ErrInvalidStructure}
+	}
+	if raw == nil {
+		return nil, &NotaryError{Message: "receipt must be a JSON object", Code: ErrInvalidStructure}
+	}
+
+	if err := ValidateReceiptMap(raw, opts); err != nil {
+		return nil, err
+	}
+
+	var receipt Receipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, &NotaryError{Message: fmt.Sprintf("invalid receipt field: %v", err), Code: ErrInvalidStructure}
+	}
+	// Re-decode without UseNumber so Raw matches what the rest of the SDK produces.
+	_ = json.Unmarshal(data, &receipt.Raw)
+	return &receipt, nil
+}
+
+// ValidateReceiptMap applies the schema rules for the receipt's version.
+func ValidateReceiptMap(receipt map[string]any, opts *ParseOptions) error {
+	version := getString(receipt, "schema_version")
+	if version == "" {
+		version = DefaultSchemaVersion
+	}
+	major, err := schemaMajor(version)
+	if err != nil {
+		return &NotaryError{Message: err.Error(), Code: "ERR_UNSUPPORTED_SCHEMA"}
+	}
+
+	schemaMu.RLock()
+	rules, ok := schemaRegistry[major]
+	schemaMu.RUnlock()
+	if !ok {
+		return &NotaryError{
+			Message: fmt.Sprintf("unsupported receipt schema version %s (supported majors: %v)", version, SupportedSchemaMajors()),
+			Code:    "ERR_UNSUPPORTED_SCHEMA",
+		}
+	}
+
+	var missing []string
+	for _, field := range rules.Required {
+		val, ok := receipt[field]
+		if !ok || val == nil || val == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return &NotaryError{Message: fmt.Sprintf("Missing required fields: %s", strings.Join(missing, ", ")), Code: ErrInvalidStructure}
+	}
+
+	if opts != nil && opts.Strict {
+		known := make(map[string]bool, len(rules.Known))
+		for _, k := range rules.Known {
+			known[k] = true
+		}
+		crit, _ := receipt["crit"].([]any)
+		for _, c := range crit {
+			name, _ := c.(string)
+			if !known[name] {
+				return &NotaryError{Message: fmt.Sprintf("unknown critical field %q", name), Code: ErrInvalidStructure}
+			}
+		}
+	}
+
+	if rules.Validate != nil {
+		if err := rules.Validate(receipt); err != nil {
+			return &NotaryError{Message: err.Error(), Code: ErrInvalidStructure}
+		}
+	}
+	return nil
+}
+
+func schemaMajor(version string) (int, error) {
+	majorStr, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	major, err := strconv.Atoi(majorStr)
+	if err != nil || major < 0 {
+		return 0, fmt.Errorf("malformed schema_version %q", version)
+	}
+	return major, nil
+}
+
+// maxReceiptDepth bounds object and array nesting in strict parsing, so the
+// recursive duplicate-key walk cannot be driven arbitrarily deep.
+const maxReceiptDepth = 64
+
+// checkDuplicateKeys rejects objects that repeat a key at any depth, which
+// encoding/json would otherwise silently resolve to the last value.
+func checkDuplicateKeys(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var walk func(depth int) error
+	walk = func(depth int) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("malformed receipt JSON: %v", err)
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok {
+			return nil
+		}
+		if depth > maxReceiptDepth {
+			return fmt.Errorf("receipt JSON nested deeper than %d levels", maxReceiptDepth)
+		}
+		switch delim {
+		case '{':
+			seen := map[string]bool{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return fmt.Errorf("malformed receipt JSON: %v", err)
+				}
+				key := keyTok.(string)
+				if seen[key] {
+					return fmt.Errorf("duplicate key %q", key)
+				}
+				seen[key] = true
+				if err := walk(depth + 1); err != nil {
+					return err
+				}
+			}
+		case '[':
+			for dec.More() {
+				if err := walk(depth + 1); err != nil {
+					return err
+				}
+			}
+		}
+		_, err = dec.Token() // closing delimiter
+		if err != nil {
+			return fmt.Errorf("malformed receipt JSON: %v", err)
+		}
+		return nil
+	}
+	return walk(1)
+}
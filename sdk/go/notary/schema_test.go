@@ -0,0 +1,80 @@
+package notary_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hellothere012/notaryos-go/notary"
+)
+
+const receiptFields = `"receipt_id":"rcpt_1","timestamp":"2026-01-01T00:00:00Z","agent_id":"agent",` +
+	`"action_type":"billing.charge","payload_hash":"abc","signature":"c2ln","signature_type":"ed25519"`
+
+func TestParseReceipt(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		// lenient and strict are the wanted error codes ("" for success).
+		lenient, strict string
+	}{
+		{"default version", `{` + receiptFields + `}`, "", ""},
+		{"additive minor", `{"schema_version":"1.7","rollout_cohort":"b",` + receiptFields + `}`, "", ""},
+		{"unknown major", `{"schema_version":"3.0",` + receiptFields + `}`, "ERR_UNSUPPORTED_SCHEMA", "ERR_UNSUPPORTED_SCHEMA"},
+		{"malformed version", `{"schema_version":"one",` + receiptFields + `}`, "ERR_UNSUPPORTED_SCHEMA", "ERR_UNSUPPORTED_SCHEMA"},
+		{"schema 2 without kid", `{"schema_version":"2.0",` + receiptFields + `}`, notary.ErrInvalidStructure, notary.ErrInvalidStructure},
+		{"schema 2 with kid", `{"schema_version":"2.0","kid":"k1",` + receiptFields + `}`, "", ""},
+		{"unknown critical field", `{"crit":["escrow_terms"],"escrow_terms":{},` + receiptFields + `}`, "", notary.ErrInvalidStructure},
+		{"known critical field", `{"crit":["key_id"],"key_id":"k1",` + receiptFields + `}`, "", ""},
+		{"duplicate key", `{"agent_id":"other",` + receiptFields + `}`, "", notary.ErrInvalidStructure},
+		{"nested duplicate key", `{"metadata":{"a":1,"a":2},` + receiptFields + `}`, "", notary.ErrInvalidStructure},
+		{"missing field", `{"receipt_id":"rcpt_1"}`, notary.ErrInvalidStructure, notary.ErrInvalidStructure},
+		{"trailing data", `{` + receiptFields + `} {}`, notary.ErrInvalidStructure, notary.ErrInvalidStructure},
+		{"not an object", `null`, notary.ErrInvalidStructure, notary.ErrInvalidStructure},
+		{"too deep", `{"metadata":` + strings.Repeat(`[`, 100) + strings.Repeat(`]`, 100) + `,` + receiptFields + `}`, "", notary.ErrInvalidStructure},
+	} {
+		for _, strict := range []bool{false, true} {
+			want := tc.lenient
+			if strict {
+				want = tc.strict
+			}
+			_, err := notary.ParseReceipt([]byte(tc.json), &notary.ParseOptions{Strict: strict})
+			var nerr *notary.NotaryError
+			switch {
+			case want == "" && err != nil:
+				t.Errorf("%s (strict %v): %v", tc.name, strict, err)
+			case want != "" && (!errors.As(err, &nerr) || nerr.Code != want):
+				t.Errorf("%s (strict %v): error %v, want code %s", tc.name, strict, err, want)
+			}
+		}
+	}
+}
+
+func TestParseReceiptTooLarge(t *testing.T) {
+	data := []byte(`{"metadata":"` + strings.Repeat("x", notary.MaxReceiptBytes) + `",` + receiptFields + `}`)
+	var nerr *notary.NotaryError
+	if _, err := notary.ParseReceipt(data, nil); !errors.As(err, &nerr) || nerr.Code != notary.ErrPayloadTooLarge {
+		t.Errorf("oversized receipt: %v", err)
+	}
+}
+
+// FuzzParseReceipt checks that ParseReceipt never panics and that strict
+// parsing accepts nothing the lenient mode rejects.
+func FuzzParseReceipt(f *testing.F) {
+	f.Add([]byte(`{` + receiptFields + `}`))
+	f.Add([]byte(`{"schema_version":"2.1","kid":"k1","crit":["kid"],` + receiptFields + `}`))
+	f.Add([]byte(`{"metadata":{"a":[1,{"b":null}],"a":2},` + receiptFields + `}`))
+	f.Add([]byte(`[[[[{}]]]]`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		lenient, lerr := notary.ParseReceipt(data, nil)
+		strict, serr := notary.ParseReceipt(data, &notary.ParseOptions{Strict: true})
+		if serr == nil && lerr != nil {
+			t.Fatalf("strict accepted what lenient rejected: %v", lerr)
+		}
+		for _, r := range []*notary.Receipt{lenient, strict} {
+			if r != nil && r.Raw == nil {
+				t.Fatal("parsed receipt has no Raw map")
+			}
+		}
+	})
+}
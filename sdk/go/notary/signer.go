@@ -0,0 +1,155 @@
+package notary
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Signer produces Ed25519 signatures for locally signed receipts and
+// co-signatures. Implementations decide where the private key lives: in
+// memory for tests (NewKeySigner), or behind HashiCorp Vault transit or a
+// cloud KMS (NewRemoteSigner), where it never enters this process.
+type Signer interface {
+	// Sign returns the pure Ed25519 signature over message.
+	Sign(message []byte) ([]byte, error)
+	// PublicKey returns the key verifiers use to check signatures.
+	PublicKey() ed25519.PublicKey
+	// KeyID identifies the key; it is written to a signed receipt's kid.
+	KeyID() string
+}
+
+// KeyIDFor derives a key ID from a public key: the first 16 hex characters
+// of its SHA-256. Signers use it when no explicit ID is configured.
+func KeyIDFor(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+type keySigner struct {
+	priv ed25519.PrivateKey
+	kid  string
+}
+
+// NewKeySigner returns a Signer backed by an in-memory private key, for
+// tests and local development. Production deployments should use a Signer
+// whose key stays in a Vault or KMS. keyID defaults to KeyIDFor.
+func NewKeySigner(priv ed25519.PrivateKey, keyID string) (Signer, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 private key length %d", len(priv))
+	}
+	pub := priv.Public().(ed25519.PublicKey)
+	if keyID == "" {
+		keyID = KeyIDFor(pub)
+	}
+	return &keySigner{priv: priv, kid: keyID}, nil
+}
+
+func (s *keySigner) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.priv, message), nil
+}
+
+func (s *keySigner) PublicKey() ed25519.PublicKey {
+	return s.priv.Public().(ed25519.PublicKey)
+}
+
+func (s *keySigner) KeyID() string {
+	return s.kid
+}
+
+type remoteSigner struct {
+	kid  string
+	pub  ed25519.PublicKey
+	sign func(message []byte) ([]byte, error)
+}
+
+// NewRemoteSigner adapts a remote signing call to Signer. It is the
+// building block for the Vault transit and cloud KMS signer modules: they
+// supply the public key fetched at startup and a function that performs
+// one sign request.
+//
+//	signer, err := notary.NewRemoteSigner("transit/notary-agent", pub,
+//	    func(msg []byte) ([]byte, error) {
+//	        return vaultTransitSign(ctx, "notary-agent", msg)
+//	    })
+//
+// Every returned signature is checked against pub, so a misconfigured key
+// name or a key rotated on the server fails at signing time rather than
+// producing receipts that never verify. keyID defaults to KeyIDFor.
+func NewRemoteSigner(keyID string, pub ed25519.PublicKey, sign func(message []byte) ([]byte, error)) (Signer, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key length %d", len(pub))
+	}
+	if sign == nil {
+		return nil, fmt.Errorf("remote signer requires a sign function")
+	}
+	if keyID == "" {
+		keyID = KeyIDFor(pub)
+	}
+	return &remoteSigner{kid: keyID, pub: pub, sign: sign}, nil
+}
+
+func (s *remoteSigner) Sign(message []byte) ([]byte, error) {
+	sig, err := s.sign(message)
+	if err != nil {
+		return nil, fmt.Errorf("remote sign with key %s: %w", s.kid, err)
+	}
+	if !ed25519.Verify(s.pub, message, sig) {
+		return nil, fmt.Errorf("remote sign with key %s: signature does not match public key", s.kid)
+	}
+	return sig, nil
+}
+
+func (s *remoteSigner) PublicKey() ed25519.PublicKey {
+	return s.pub
+}
+
+func (s *remoteSigner) KeyID() string {
+	return s.kid
+}
+
+// SignDetached signs an already-canonicalized message and returns the
+// base64 signature, the counterpart of OfflineVerifier.VerifyDetached with
+// alg "Ed25519". Use it for co-signatures over receipts issued elsewhere.
+func SignDetached(signer Signer, message []byte) (string, error) {
+	sig, err := signer.Sign(message)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// SignReceipt signs receipt in place over canonical format v1, setting kid,
+// signature_type and signature. Receipts using canonical v2 (alg Ed25519ph,
+// or schema_version 2.x) are rejected: pure Ed25519 signers cannot produce
+// pre-hashed signatures.
+func SignReceipt(signer Signer, receipt map[string]any) error {
+	if CanonicalVersion(receipt) != CanonicalV1 {
+		return fmt.Errorf("cannot sign a canonical v2 (%s) receipt with a pure Ed25519 signer", AlgEd25519ph)
+	}
+	receipt["kid"] = signer.KeyID()
+	receipt["signature_type"] = "ed25519"
+	sig, err := SignDetached(signer, []byte(buildCanonicalV1(receipt)))
+	if err != nil {
+		return err
+	}
+	receipt["signature"] = sig
+	return nil
+}
+
+// TrustSigner adds signer's public key to the verifier, so receipts and
+// co-signatures it produced verify offline alongside server-issued ones. A
+// zero OfflineVerifier trusts only the signers added this way.
+func (v *OfflineVerifier) TrustSigner(signer Signer) {
+	if v.keys == nil {
+		v.keys = make(map[string]ed25519.PublicKey)
+		v.meta = make(map[string]KeyInfo)
+	}
+	kid := signer.KeyID()
+	v.keys[kid] = signer.PublicKey()
+	if _, ok := v.meta[kid]; !ok {
+		v.meta[kid] = KeyInfo{KeyID: kid, Alg: "Ed25519", Status: KeyStatusActive}
+	}
+}
@@ -0,0 +1,123 @@
+package notary
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClockSkew is the measured offset between the server's clock and this
+// host's.
+type ClockSkew struct {
+	// Offset is server time minus local time: positive when the local clock
+	// is behind.
+	Offset time.Duration
+	// Samples is the number of responses the estimate is built from.
+	Samples int
+	// MeasuredAt is the local time of the latest sample (zero if none).
+	MeasuredAt time.Time
+}
+
+// skewSmoothing weights each new sample in the running offset. Date headers
+// have one-second resolution, so single samples are noisy; a clock step
+// larger than skewStep replaces the estimate outright.
+const (
+	skewSmoothing = 0.2
+	skewStep      = 5 * time.Second
+)
+
+// serverClock tracks the server/client offset from response times.
+type serverClock struct {
+	mu   sync.Mutex
+	skew ClockSkew
+}
+
+// observe records a server timestamp seen in a response to a request sent
+// at sent and answered at received. A server time with second resolution
+// is taken to be the middle of its second; a precise one (resolution 0)
+// replaces the running estimate.
+func (c *serverClock) observe(server time.Time, resolution time.Duration, sent, received time.Time) {
+	local := sent.Add(received.Sub(sent) / 2)
+	sample := server.Add(resolution / 2).Sub(local)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	diff := sample - c.skew.Offset
+	if c.skew.Samples == 0 || resolution == 0 || diff > skewStep || diff < -skewStep {
+		c.skew.Offset = sample
+	} else {
+		c.skew.Offset += time.Duration(float64(diff) * skewSmoothing)
+	}
+	c.skew.Samples++
+	c.skew.MeasuredAt = received
+}
+
+func (c *serverClock) get() ClockSkew {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.skew
+}
+
+// clockTransport measures skew from the Date header of every response.
+type clockTransport struct {
+	next  http.RoundTripper
+	clock *serverClock
+}
+
+func (t *clockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		if date, perr := http.ParseTime(resp.Header.Get("Date")); perr == nil {
+			t.clock.observe(date, time.Second, sent, time.Now())
+		}
+	}
+	return resp, err
+}
+
+func (t *clockTransport) CloseIdleConnections() {
+	if ci, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// ClockSkew returns the measured server/client clock offset. It is
+// measured from every response whether or not Config.TimeSync is set, so
+// it can be exported to monitoring either way.
+func (c *Client) ClockSkew() ClockSkew {
+	return c.clock.get()
+}
+
+// Now returns the current time, corrected by the measured clock offset
+// when Config.TimeSync is set. The SDK uses it for the timestamps it
+// generates and for time-based receipt checks. A nil Client returns the
+// local time, so dry-run helpers such as RecordAction work without one.
+func (c *Client) Now() time.Time {
+	now := time.Now()
+	if c != nil && c.timeSync {
+		now = now.Add(c.clock.get().Offset)
+	}
+	return now
+}
+
+// SyncTime samples the server clock from the /status timestamp, which is
+// finer-grained than Date headers when the server reports sub-seconds.
+// Call it at startup on hosts with known drift.
+func (c *Client) SyncTime() (ClockSkew, error) {
+	sent := time.Now()
+	status, err := c.Status()
+	received := time.Now()
+	if err != nil {
+		return c.ClockSkew(), err
+	}
+	server, err := time.Parse(time.RFC3339Nano, status.Timestamp)
+	if err != nil {
+		return c.ClockSkew(), &NotaryError{Message: "status timestamp is missing or unparseable", Code: "ERR_PARSE"}
+	}
+	resolution := time.Second
+	if server.Nanosecond() != 0 {
+		resolution = 0
+	}
+	c.clock.observe(server, resolution, sent, received)
+	return c.ClockSkew(), nil
+}
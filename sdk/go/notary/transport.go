@@ -0,0 +1,237 @@
+package notary
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Transport defaults tuned for sustained request rates against one host.
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 32
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultBreakerCooldown     = 30 * time.Second
+)
+
+// TransportConfig tunes the client's HTTP transport. Zero values use the
+// SDK defaults above.
+type TransportConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// DisableHTTP2 turns off the default HTTP/2 negotiation.
+	DisableHTTP2 bool
+	// HealthCheckInterval enables a background GET /status ping that keeps
+	// connections warm and feeds the circuit breaker (0 = disabled).
+	HealthCheckInterval time.Duration
+	// BreakerThreshold opens the circuit after this many consecutive
+	// failures (0 = no circuit breaker).
+	BreakerThreshold int
+	// BreakerCooldown is how long the circuit stays open before a trial
+	// request is allowed (default 30s).
+	BreakerCooldown time.Duration
+}
+
+func newTransport(cfg TransportConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = DefaultMaxIdleConns
+	t.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	t.IdleConnTimeout = DefaultIdleConnTimeout
+	t.ForceAttemptHTTP2 = !cfg.DisableHTTP2
+	t.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+
+	if cfg.MaxIdleConns > 0 {
+		t.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	}
+	return t
+}
+
+// Circuit breaker states reported by Client.BreakerState.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// circuitBreaker fails fast after repeated upstream failures.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	trial     bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request may proceed. Once the cooldown passes,
+// a single trial request is let through (half-open).
+func (b *circuitBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Since(b.openedAt) < b.cooldown || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.failures = 0
+	b.trial = false
+	b.mu.Unlock()
+}
+
+func (b *circuitBreaker) failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.failures++
+	b.trial = false
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+	b.mu.Unlock()
+}
+
+func (b *circuitBreaker) state() string {
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.failures < b.threshold:
+		return BreakerClosed
+	case time.Since(b.openedAt) >= b.cooldown:
+		return BreakerHalfOpen
+	default:
+		return BreakerOpen
+	}
+}
+
+// BreakerState returns the circuit breaker state ("closed" when disabled).
+func (c *Client) BreakerState() string {
+	return c.breaker.state()
+}
+
+// send performs a single request through the circuit breaker, for calls
+// that do not go through doCall's retries. Errors are *NotaryError values:
+// ERR_CIRCUIT_OPEN when the breaker refuses the request, otherwise as
+// connectionError reports them.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if !c.breaker.allow() {
+		return nil, &NotaryError{Message: "circuit breaker open: service is failing, not sending request", Code: "ERR_CIRCUIT_OPEN"}
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.breaker.failure()
+		return nil, connectionError(req.Context(), err)
+	}
+	if resp.StatusCode >= 500 {
+		c.breaker.failure()
+	} else {
+		c.breaker.success()
+	}
+	return resp, nil
+}
+
+// startHealthCheck pings /status every interval until Close is called.
+func (c *Client) startHealthCheck(interval time.Duration) {
+	c.stopHealth = make(chan struct{})
+	c.healthDone = make(chan struct{})
+	go func() {
+		defer close(c.healthDone)
+		c.ping() // pre-warm a connection before the first real request
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stopHealth:
+				return
+			case <-ticker.C:
+				c.ping()
+			}
+		}
+	}()
+}
+
+// ping performs one health request, bypassing retries and the breaker gate
+// so it can close an open circuit once the service recovers.
+func (c *Client) ping() {
+	req, err := http.NewRequest("GET", c.baseURL+"/v1/notary/status", nil)
+	if err != nil {
+		return
+	}
+	c.setHeaders(req)
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.breaker.failure()
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		c.breaker.failure()
+		return
+	}
+	c.breaker.success()
+}
+
+// Close stops background work (the health ping) and releases idle
+// connections. The client must not be used afterwards.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		if c.stopHealth != nil {
+			close(c.stopHealth)
+			<-c.healthDone
+		}
+		c.httpClient.CloseIdleConnections()
+	})
+}
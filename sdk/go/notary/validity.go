@@ -0,0 +1,250 @@
+package notary
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultValidityLead is how long before expiry the tracker notifies by default.
+const DefaultValidityLead = 5 * time.Minute
+
+// TrackedCounterfactual is a counterfactual receipt registered with a ValidityTracker.
+type TrackedCounterfactual struct {
+	ReceiptHash string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+	// Options are the issue options used, reused when re-issuing.
+	Options CounterfactualIssueOptions
+	// Result is the raw issue response.
+	Result map[string]any
+}
+
+// ValidityTrackerConfig configures a ValidityTracker.
+type ValidityTrackerConfig struct {
+	// Lead is how long before expiry to notify (default 5m).
+	Lead time.Duration
+	// OnExpiring is called (in its own goroutine) when a receipt nears expiry.
+	OnExpiring func(t *TrackedCounterfactual)
+	// AutoReissue re-issues the receipt automatically when it nears expiry.
+	AutoReissue bool
+	// OnReissued is called after a successful automatic re-issue.
+	OnReissued func(old, renewed *TrackedCounterfactual)
+	// OnError is called when an automatic re-issue fails.
+	OnError func(t *TrackedCounterfactual, err error)
+}
+
+// ValidityTracker watches issued counterfactual receipts and notifies shortly
+// before their validity_window_minutes lapse, so proofs of non-action can be
+// renewed instead of silently expiring.
+//
+//	tracker := notary.NewValidityTracker(client.Counterfactual(), &notary.ValidityTrackerConfig{
+//	    AutoReissue: true,
+//	})
+//	defer tracker.Close()
+//	result, tracked, err := tracker.Issue(opts)
+type ValidityTracker struct {
+	cf       *CounterfactualClient
+	config   ValidityTrackerConfig
+	expiring chan *TrackedCounterfactual
+	mu       sync.Mutex
+	items    map[string]*TrackedCounterfactual
+	timers   map[string]*time.Timer
+	closed   bool
+}
+
+// NewValidityTracker creates a tracker bound to a counterfactual sub-client.
+// Pass nil for config to use defaults.
+func NewValidityTracker(cf *CounterfactualClient, config *ValidityTrackerConfig) *ValidityTracker {
+	cfg := ValidityTrackerConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Lead <= 0 {
+		cfg.Lead = DefaultValidityLead
+	}
+	return &ValidityTracker{
+		cf:       cf,
+		config:   cfg,
+		expiring: make(chan *TrackedCounterfactual, 64),
+		items:    make(map[string]*TrackedCounterfactual),
+		timers:   make(map[string]*time.Timer),
+	}
+}
+
+// Issue issues a v1 counterfactual receipt and registers it for tracking.
+func (t *ValidityTracker) Issue(opts CounterfactualIssueOptions) (map[string]any, *TrackedCounterfactual, error) {
+	result, err := t.cf.Issue(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracked, err := t.Track(result, opts)
+	if err != nil {
+		return result, nil, err
+	}
+	return result, tracked, nil
+}
+
+// Track registers an already-issued counterfactual receipt. The issue time is
+// taken from the receipt timestamp when present, otherwise from now.
+func (t *ValidityTracker) Track(result map[string]any, opts CounterfactualIssueOptions) (*TrackedCounterfactual, error) {
+	hash := getString(result, "receipt_hash")
+	receipt, _ := result["receipt"].(map[string]any)
+	if hash == "" && receipt != nil {
+		hash = getString(receipt, "receipt_hash")
+	}
+	if hash == "" {
+		return nil, fmt.Errorf("counterfactual result has no receipt_hash")
+	}
+
+	window := opts.ValidityWindowMinutes
+	now := t.cf.client.Now()
+	issuedAt := now
+	if receipt != nil {
+		if ts, err := time.Parse(time.RFC3339, getString(receipt, "timestamp")); err == nil {
+			issuedAt = ts
+		}
+		if w, ok := receipt["validity_window_minutes"].(float64); ok && w > 0 {
+			window = int(w)
+		}
+	}
+	if window == 0 {
+		window = 60
+	}
+	opts.ValidityWindowMinutes = window
+
+	tracked := &TrackedCounterfactual{
+		ReceiptHash: hash,
+		IssuedAt:    issuedAt,
+		ExpiresAt:   issuedAt.Add(time.Duration(window) * time.Minute),
+		Options:     opts,
+		Result:      result,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, fmt.Errorf("validity tracker is closed")
+	}
+	t.stopLocked(hash)
+	t.items[hash] = tracked
+	// ExpiresAt is in server time; measure against the corrected clock.
+	fireIn := tracked.ExpiresAt.Add(-t.config.Lead).Sub(now)
+	if fireIn < 0 {
+		fireIn = 0
+	}
+	t.timers[hash] = time.AfterFunc(fireIn, func() { t.fire(hash) })
+	return tracked, nil
+}
+
+// Untrack stops watching a receipt.
+func (t *ValidityTracker) Untrack(receiptHash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopLocked(receiptHash)
+	delete(t.items, receiptHash)
+}
+
+// Reissue issues a fresh counterfactual receipt with the same options as a
+// tracked one, linking the old receipt via provenance_refs, and swaps tracking
+// over to the new receipt.
+func (t *ValidityTracker) Reissue(receiptHash string) (*TrackedCounterfactual, error) {
+	return t.Extend(receiptHash, 0)
+}
+
+// Extend is Reissue with a new validity window (0 keeps the original window).
+func (t *ValidityTracker) Extend(receiptHash string, windowMinutes int) (*TrackedCounterfactual, error) {
+	t.mu.Lock()
+	old, ok := t.items[receiptHash]
+	t.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("receipt %s is not tracked", receiptHash)
+	}
+
+	opts := old.Options
+	if windowMinutes > 0 {
+		opts.ValidityWindowMinutes = windowMinutes
+	}
+	opts.ProvenanceRefs = append(append([]string(nil), old.Options.ProvenanceRefs...), old.ReceiptHash)
+
+	result, err := t.cf.Issue(opts)
+	if err != nil {
+		return nil, err
+	}
+	renewed, err := t.Track(result, opts)
+	if err != nil {
+		return nil, err
+	}
+	t.Untrack(receiptHash)
+	return renewed, nil
+}
+
+// Expiring returns a channel that receives receipts nearing expiry.
+// Sends are non-blocking; if nobody reads, notifications are dropped
+// (OnExpiring is still called).
+func (t *ValidityTracker) Expiring() <-chan *TrackedCounterfactual {
+	return t.expiring
+}
+
+// Tracked returns a snapshot of all tracked receipts.
+func (t *ValidityTracker) Tracked() []*TrackedCounterfactual {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]*TrackedCounterfactual, 0, len(t.items))
+	for _, item := range t.items {
+		out = append(out, item)
+	}
+	return out
+}
+
+// Close stops all timers. Tracked receipts are left to expire.
+func (t *ValidityTracker) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	for hash := range t.timers {
+		t.stopLocked(hash)
+	}
+}
+
+func (t *ValidityTracker) stopLocked(hash string) {
+	if timer, ok := t.timers[hash]; ok {
+		timer.Stop()
+		delete(t.timers, hash)
+	}
+}
+
+func (t *ValidityTracker) fire(hash string) {
+	t.mu.Lock()
+	tracked, ok := t.items[hash]
+	delete(t.timers, hash)
+	closed := t.closed
+	t.mu.Unlock()
+	if !ok || closed {
+		return
+	}
+
+	select {
+	case t.expiring <- tracked:
+	default:
+	}
+	if t.config.OnExpiring != nil {
+		go t.config.OnExpiring(tracked)
+	}
+
+	if t.config.AutoReissue {
+		renewed, err := t.Reissue(hash)
+		if err != nil {
+			if t.config.OnError != nil {
+				t.config.OnError(tracked, err)
+			}
+			return
+		}
+		if t.config.OnReissued != nil {
+			t.config.OnReissued(tracked, renewed)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package notary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultVerifyBaseURL is the public verification site.
+const DefaultVerifyBaseURL = "https://notaryos.org"
+
+// VerifyURLOptions customizes a public verify page URL.
+type VerifyURLOptions struct {
+	// Params are appended as query parameters (e.g. utm_source, ref).
+	Params map[string]string
+	// Fragment is appended after '#'.
+	Fragment string
+}
+
+// ShortLink is a human-friendly verification link from the short-link endpoint.
+type ShortLink struct {
+	Code        string `json:"code"`
+	ShortURL    string `json:"short_url"`
+	ReceiptHash string `json:"receipt_hash"`
+	TargetURL   string `json:"target_url"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+}
+
+// ShortLinkOptions holds optional parameters for creating short links.
+type ShortLinkOptions struct {
+	// Label is a human-readable note stored with the link (e.g. invoice number).
+	Label string
+	// ExpiresInDays limits the link lifetime (0 = server default).
+	ExpiresInDays int
+}
+
+// BuildVerifyURL constructs the public verify page URL for a receipt hash,
+// e.g. for receipts loaded from storage that no longer carry VerifyURL.
+// Pass "" for baseURL to use DefaultVerifyBaseURL and nil for opts.
+//
+//	link, err := notary.BuildVerifyURL("", receipt.ReceiptHash, &notary.VerifyURLOptions{
+//	    Params: map[string]string{"ref": "invoice-1042"},
+//	})
+func BuildVerifyURL(baseURL, receiptHash string, opts *VerifyURLOptions) (string, error) {
+	if receiptHash == "" {
+		return "", fmt.Errorf("receipt hash is required")
+	}
+	if baseURL == "" {
+		baseURL = DefaultVerifyBaseURL
+	}
+
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid base URL: %q", baseURL)
+	}
+	u = u.JoinPath("r", receiptHash)
+
+	if opts != nil {
+		if len(opts.Params) > 0 {
+			q := u.Query()
+			for k, v := range opts.Params {
+				q.Set(k, v)
+			}
+			u.RawQuery = q.Encode()
+		}
+		u.Fragment = opts.Fragment
+	}
+	return u.String(), nil
+}
+
+// CreateShortLink asks the server for a short verification link for a receipt.
+//
+//	link, err := client.CreateShortLink(receipt.ReceiptHash, nil)
+//	fmt.Println(link.ShortURL)
+func (c *Client) CreateShortLink(receiptHash string, opts *ShortLinkOptions) (*ShortLink, error) {
+	body := map[string]any{"receipt_hash": receiptHash}
+	if opts != nil {
+		if opts.Label != "" {
+			body["label"] = opts.Label
+		}
+		if opts.ExpiresInDays > 0 {
+			body["expires_in_days"] = opts.ExpiresInDays
+		}
+	}
+
+	respBody, err := c.doRequest("POST", "/links", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var link ShortLink
+	if err := json.Unmarshal(respBody, &link); err != nil {
+		return nil, &NotaryError{Message: "failed to parse short link", Code: "ERR_PARSE"}
+	}
+	return &link, nil
+}
+
+// ResolveShortLink resolves a short-link code (or full short URL) back to its
+// receipt hash (public endpoint).
+func (c *Client) ResolveShortLink(codeOrURL string) (*ShortLink, error) {
+	code := codeOrURL
+	if u, err := url.Parse(codeOrURL); err == nil && u.Host != "" {
+		code = strings.Trim(u.Path, "/")
+		if i := strings.LastIndex(code, "/"); i >= 0 {
+			code = code[i+1:]
+		}
+	}
+	if code == "" {
+		return nil, &NotaryError{Message: "short link code is required", Code: ErrValidationFailed}
+	}
+
+	req, err := http.NewRequest("GET", c.baseURL+"/v1/notary/links/"+url.PathEscape(code), nil)
+	if err != nil {
+		return nil, &NotaryError{Message: fmt.Sprintf("failed to create request: %v", err), Code: "ERR_REQUEST"}
+	}
+	c.setHeaders(req)
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	meta := newResponseMeta(resp)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &NotaryError{Message: "failed to read response", Code: "ERR_READ", Meta: meta}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &NotaryError{Message: string(respBody), Code: "ERR_SHORT_LINK", Status: resp.StatusCode, Meta: meta}
+	}
+
+	var link ShortLink
+	if err := json.Unmarshal(respBody, &link); err != nil {
+		return nil, &NotaryError{Message: "failed to parse short link", Code: "ERR_PARSE", Meta: meta}
+	}
+	if link.Code == "" {
+		link.Code = code
+	}
+	return &link, nil
+}
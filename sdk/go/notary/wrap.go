@@ -3,6 +3,7 @@ package notary
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 )
@@ -17,8 +18,45 @@ type WrapConfig struct {
 	FireAndForget bool
 	// MaxPayloadBytes limits the receipt payload size (default 4096)
 	MaxPayloadBytes int
-	// DryRun logs instead of issuing receipts (default false)
+	// DryRun records receipts to DryRunSink instead of issuing them (default false)
 	DryRun bool
+	// DryRunSink receives dry-run records (default: LogDryRunSink on the standard logger)
+	DryRunSink DryRunSink
+	// Priority is the queue lane for these receipts (default PriorityNormal)
+	Priority Priority
+}
+
+// DryRunRecord describes a receipt that would have been issued.
+type DryRunRecord struct {
+	AgentName  string
+	ActionType string
+	Payload    map[string]any
+	Time       time.Time
+}
+
+// DryRunSink receives dry-run records in place of issued receipts.
+type DryRunSink interface {
+	Record(rec DryRunRecord)
+}
+
+// Logger is the minimal logging interface used by the SDK; *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// LogDryRunSink writes dry-run records to a Logger (log.Default() if nil).
+type LogDryRunSink struct {
+	Logger Logger
+}
+
+// Record logs the record as a single line.
+func (s LogDryRunSink) Record(rec DryRunRecord) {
+	logger := s.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	data, _ := json.Marshal(rec.Payload)
+	logger.Printf("[NotaryOS DRY RUN] %s: %s", rec.ActionType, string(data))
 }
 
 // ReceiptMiddleware returns an HTTP middleware that auto-receipts requests.
@@ -30,41 +68,146 @@ type receiptQueueItem struct {
 	actionType string
 	payload    map[string]any
 	prevHash   string
+	priority   Priority
+	enqueuedAt time.Time
+}
+
+// Priority selects a ReceiptQueue lane. The consumer always drains high
+// before normal and normal before low, so security-critical receipts never
+// wait behind bulk telemetry. The numeric values are not an ordering:
+// PriorityNormal is 0 so that it is the zero value, and High and Low follow.
+// Compare priorities by name, never with < or >.
+type Priority int
+
+const (
+	// PriorityNormal is the default lane, used by Enqueue and by a
+	// WrapConfig that leaves Priority unset.
+	PriorityNormal Priority = iota
+	// PriorityHigh is drained before every other lane; use it for receipts
+	// that must not wait, such as security and payment events.
+	PriorityHigh
+	// PriorityLow is drained only when the other lanes are empty; use it for
+	// bulk telemetry that may lag or be dropped under load.
+	PriorityLow
+)
+
+// String returns "high", "normal" or "low".
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// DropPolicy decides what Enqueue does when a lane is full.
+type DropPolicy int
+
+const (
+	// DropNewest rejects the new item: it goes to the overflow sink when one
+	// is set and is dropped otherwise. This is the default.
+	DropNewest DropPolicy = iota
+	// DropOldest evicts the lane's oldest item (to the overflow sink or
+	// dropped) to make room for the new one.
+	DropOldest
+	// Block makes Enqueue wait for room, applying backpressure to the caller.
+	Block
+)
+
+// LaneConfig configures one priority lane.
+type LaneConfig struct {
+	// Capacity is the lane's buffer size (0 keeps the current size).
+	Capacity int
+	Drop     DropPolicy
+}
+
+type lane struct {
+	items    []receiptQueueItem
+	capacity int
+	drop     DropPolicy
+	dropped  int
 }
 
+// laneOrder is the drain order; it also indexes ReceiptQueue.lanes.
+var laneOrder = [...]Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
 // ReceiptQueue manages background receipt issuance.
 type ReceiptQueue struct {
 	client   *Client
-	ch       chan receiptQueueItem
+	lanes    [len(laneOrder)]*lane
+	cond     *sync.Cond
+	closed   bool
 	lastHash string
 	mu       sync.Mutex
 	issued   int
 	failed   int
-	dropped  int
+	spilled  int
+	overflow OverflowSink
 	done     chan struct{}
 	once     sync.Once
 }
 
-// NewReceiptQueue creates a background receipt queue.
+// NewReceiptQueue creates a background receipt queue. Each priority lane
+// holds bufSize items and uses DropNewest until changed with SetLane.
 func NewReceiptQueue(client *Client, bufSize int) *ReceiptQueue {
 	if bufSize <= 0 {
 		bufSize = 1000
 	}
 	q := &ReceiptQueue{
 		client: client,
-		ch:     make(chan receiptQueueItem, bufSize),
 		done:   make(chan struct{}),
 	}
+	q.cond = sync.NewCond(&q.mu)
+	for i := range q.lanes {
+		q.lanes[i] = &lane{capacity: bufSize}
+	}
 	go q.consumer()
 	return q
 }
 
+func (q *ReceiptQueue) lane(p Priority) *lane {
+	for i, lp := range laneOrder {
+		if lp == p {
+			return q.lanes[i]
+		}
+	}
+	return q.lanes[1] // unknown priorities are treated as normal
+}
+
+// SetLane configures the capacity and drop policy of one priority lane.
+//
+//	queue.SetLane(notary.PriorityHigh, notary.LaneConfig{Capacity: 500, Drop: notary.Block})
+//	queue.SetLane(notary.PriorityLow, notary.LaneConfig{Capacity: 5000, Drop: notary.DropOldest})
+func (q *ReceiptQueue) SetLane(p Priority, cfg LaneConfig) {
+	q.mu.Lock()
+	l := q.lane(p)
+	if cfg.Capacity > 0 {
+		l.capacity = cfg.Capacity
+	}
+	l.drop = cfg.Drop
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
 func (q *ReceiptQueue) consumer() {
 	defer close(q.done)
-	for item := range q.ch {
-		receipt, err := q.client.Issue(item.actionType, item.payload, IssueOptions{
-			PreviousReceiptHash: item.prevHash,
-		})
+	for {
+		q.mu.Lock()
+		item, ok := q.popLocked()
+		for !ok && !q.closed {
+			q.cond.Wait()
+			item, ok = q.popLocked()
+		}
+		q.mu.Unlock()
+		if !ok {
+			return // closed and drained
+		}
+		q.cond.Broadcast() // wake producers blocked on a full lane
+
+		receipt, err := q.client.Issue(item.actionType, item.payload, WithPreviousHash(item.prevHash))
 		if err != nil {
 			q.mu.Lock()
 			q.failed++
@@ -80,43 +223,174 @@ func (q *ReceiptQueue) consumer() {
 	}
 }
 
-// Enqueue adds a receipt job. Non-blocking — drops if full.
+// popLocked removes the next item, highest priority first.
+func (q *ReceiptQueue) popLocked() (receiptQueueItem, bool) {
+	for _, l := range q.lanes {
+		if len(l.items) > 0 {
+			item := l.items[0]
+			l.items[0] = receiptQueueItem{}
+			l.items = l.items[1:]
+			return item, true
+		}
+	}
+	return receiptQueueItem{}, false
+}
+
+// Enqueue adds a normal-priority receipt job. See EnqueuePriority.
 func (q *ReceiptQueue) Enqueue(actionType string, payload map[string]any) {
-	q.mu.Lock()
-	prevHash := q.lastHash
-	q.mu.Unlock()
+	q.EnqueuePriority(PriorityNormal, actionType, payload)
+}
 
-	select {
-	case q.ch <- receiptQueueItem{
+// EnqueuePriority adds a receipt job to the lane for p. When the lane is
+// full its DropPolicy applies: by default the item goes to the overflow
+// sink when one is set, and is dropped otherwise.
+func (q *ReceiptQueue) EnqueuePriority(p Priority, actionType string, payload map[string]any) {
+	q.mu.Lock()
+	l := q.lane(p)
+	item := receiptQueueItem{
 		actionType: actionType,
 		payload:    payload,
-		prevHash:   prevHash,
-	}:
+		prevHash:   q.lastHash,
+		priority:   p,
+		enqueuedAt: time.Now().UTC(),
+	}
+
+	var rejected *receiptQueueItem
+	switch {
+	case q.closed:
+		rejected = &item
+	case len(l.items) < l.capacity:
+		l.items = append(l.items, item)
+	case l.drop == Block:
+		for len(l.items) >= l.capacity && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			rejected = &item
+		} else {
+			l.items = append(l.items, item)
+		}
+	case l.drop == DropOldest:
+		evicted := l.items[0]
+		l.items = append(l.items[1:], item)
+		rejected = &evicted
 	default:
-		q.mu.Lock()
-		q.dropped++
+		rejected = &item
+	}
+	sink := q.overflow
+	q.mu.Unlock()
+	q.cond.Broadcast()
+
+	if rejected != nil {
+		q.spill(sink, *rejected)
+	}
+}
+
+// spill sends a rejected item to the overflow sink, or counts it dropped.
+func (q *ReceiptQueue) spill(sink OverflowSink, item receiptQueueItem) {
+	if sink != nil {
+		err := sink.Write(QueueItem{
+			ActionType: item.actionType,
+			Payload:    item.payload,
+			PrevHash:   item.prevHash,
+			Priority:   item.priority,
+			EnqueuedAt: item.enqueuedAt,
+		})
+		if err == nil {
+			q.mu.Lock()
+			q.spilled++
+			q.mu.Unlock()
+			return
+		}
+	}
+
+	q.mu.Lock()
+	q.lane(item.priority).dropped++
+	q.mu.Unlock()
+}
+
+// TryEnqueue adds a normal-priority receipt job if there is room and
+// reports whether it did. See TryEnqueuePriority.
+func (q *ReceiptQueue) TryEnqueue(actionType string, payload map[string]any) bool {
+	return q.TryEnqueuePriority(PriorityNormal, actionType, payload)
+}
+
+// TryEnqueuePriority adds a receipt job to the lane for p if it has room
+// and reports whether it did. Unlike EnqueuePriority it never blocks,
+// evicts, spills to the overflow sink, or counts a drop.
+func (q *ReceiptQueue) TryEnqueuePriority(p Priority, actionType string, payload map[string]any) bool {
+	q.mu.Lock()
+	l := q.lane(p)
+	if q.closed || len(l.items) >= l.capacity {
 		q.mu.Unlock()
+		return false
+	}
+	l.items = append(l.items, receiptQueueItem{
+		actionType: actionType,
+		payload:    payload,
+		prevHash:   q.lastHash,
+		priority:   p,
+		enqueuedAt: time.Now().UTC(),
+	})
+	q.mu.Unlock()
+	q.cond.Broadcast()
+	return true
+}
+
+// SetOverflowSink routes items that don't fit in the queue to sink instead
+// of dropping them. Pass nil to restore drop-on-full behavior.
+func (q *ReceiptQueue) SetOverflowSink(sink OverflowSink) {
+	q.mu.Lock()
+	q.overflow = sink
+	q.mu.Unlock()
+}
+
+// Free returns how many items can currently be enqueued across all lanes
+// without overflowing.
+func (q *ReceiptQueue) Free() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	free := 0
+	for _, l := range q.lanes {
+		if n := l.capacity - len(l.items); n > 0 {
+			free += n
+		}
 	}
+	return free
 }
 
-// Close shuts down the queue and waits for pending items.
+// Close shuts down the queue and waits for pending items. Producers
+// blocked on a full lane are released and their items rejected.
 func (q *ReceiptQueue) Close() {
 	q.once.Do(func() {
-		close(q.ch)
+		q.mu.Lock()
+		q.closed = true
+		q.mu.Unlock()
+		q.cond.Broadcast()
 		<-q.done
 	})
 }
 
-// Stats returns queue statistics.
+// Stats returns queue statistics, with per-lane "pending_<lane>" and
+// "dropped_<lane>" counts alongside the totals.
 func (q *ReceiptQueue) Stats() map[string]int {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	return map[string]int{
+	stats := map[string]int{
 		"issued":  q.issued,
 		"failed":  q.failed,
-		"dropped": q.dropped,
-		"pending": len(q.ch),
+		"dropped": 0,
+		"spilled": q.spilled,
+		"pending": 0,
 	}
+	for i, l := range q.lanes {
+		name := laneOrder[i].String()
+		stats["pending_"+name] = len(l.items)
+		stats["dropped_"+name] = l.dropped
+		stats["pending"] += len(l.items)
+		stats["dropped"] += l.dropped
+	}
+	return stats
 }
 
 // WrappedAction represents a function call that should be auto-receipted.
@@ -157,7 +431,7 @@ func RecordAction(
 		"agent":          agentName,
 		"auto_receipt":   true,
 		"function":       functionName,
-		"timestamp":      time.Now().UTC().Format(time.RFC3339),
+		"timestamp":      client.Now().UTC().Format(time.RFC3339),
 		"duration_ms":    durationMs,
 		"status":         status,
 		"error_type":     errorType,
@@ -165,14 +439,28 @@ func RecordAction(
 		"result_summary": safeRepr(result),
 	}
 
+	// Reject unregistered action types before they are queued or dry-run.
+	if err := client.checkAction(functionName); err != nil {
+		client.fireIssueFailed(err, functionName, payload, nil)
+		return
+	}
+
 	if config.DryRun {
-		data, _ := json.Marshal(payload)
-		fmt.Printf("[NotaryOS DRY RUN] %s: %s\n", functionName, string(data))
+		sink := config.DryRunSink
+		if sink == nil {
+			sink = LogDryRunSink{}
+		}
+		sink.Record(DryRunRecord{
+			AgentName:  agentName,
+			ActionType: functionName,
+			Payload:    payload,
+			Time:       client.Now().UTC(),
+		})
 		return
 	}
 
 	if queue != nil && (config.FireAndForget || config.Mode != "") {
-		queue.Enqueue(functionName, payload)
+		queue.EnqueuePriority(config.Priority, functionName, payload)
 	} else {
 		// Synchronous issuance
 		client.Issue(functionName, payload)
@@ -0,0 +1,368 @@
+package notarytest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellothere012/notaryos-go/notary"
+)
+
+// counterfactualState holds the mock's counterfactual receipts. It is
+// guarded by MockServer.mu.
+type counterfactualState struct {
+	receipts map[string]map[string]any // receipt hash -> receipt
+	order    []string
+	commits  map[string]*mockCommit // receipt hash -> commit-reveal state
+	signals  map[string][]string    // receipt hash -> corroboration signals
+}
+
+type mockCommit struct {
+	commitment     string // hex SHA-256 of the decision reason
+	revealAfter    time.Time
+	revealDeadline time.Time
+	revealed       bool
+}
+
+func newCounterfactualState() counterfactualState {
+	return counterfactualState{
+		receipts: make(map[string]map[string]any),
+		commits:  make(map[string]*mockCommit),
+		signals:  make(map[string][]string),
+	}
+}
+
+// counterfactualEndpoint maps a path below /v1/notary/counterfactual/ to
+// its endpoint name.
+func counterfactualEndpoint(method, path string) string {
+	parts := strings.Split(path, "/")
+	if method == http.MethodPost && len(parts) == 1 {
+		switch parts[0] {
+		case "issue":
+			return EndpointCounterfactualIssue
+		case "commit":
+			return EndpointCounterfactualCommit
+		case "reveal":
+			return EndpointCounterfactualReveal
+		case "corroborate":
+			return EndpointCounterfactualCorroborate
+		}
+		return ""
+	}
+	if method != http.MethodGet {
+		return ""
+	}
+	switch {
+	case len(parts) == 2 && parts[0] == "r":
+		return EndpointCounterfactualGet
+	case len(parts) == 3 && parts[0] == "r" && parts[2] == "certificate":
+		return EndpointCounterfactualCertificate
+	case len(parts) == 2 && parts[0] == "agent":
+		return EndpointCounterfactualList
+	case len(parts) == 2 && parts[0] == "commit-status":
+		return EndpointCounterfactualCommitStatus
+	case len(parts) == 3 && parts[0] == "chain" && parts[2] == "verify":
+		return EndpointCounterfactualChain
+	}
+	return ""
+}
+
+func (s *MockServer) handleCounterfactual(endpoint string, r *http.Request, corruptRate float64) *mockResponse {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/notary/counterfactual/"), "/")
+	switch endpoint {
+	case EndpointCounterfactualIssue:
+		return s.handleCounterfactualIssue(r, false, corruptRate)
+	case EndpointCounterfactualCommit:
+		return s.handleCounterfactualIssue(r, true, corruptRate)
+	case EndpointCounterfactualReveal:
+		return s.handleReveal(r)
+	case EndpointCounterfactualCorroborate:
+		return s.handleCorroborate(r)
+	case EndpointCounterfactualGet:
+		return s.handleCounterfactualGet(parts[1], corruptRate)
+	case EndpointCounterfactualCertificate:
+		return s.handleCertificate(parts[1], r.URL.Query().Get("format"))
+	case EndpointCounterfactualList:
+		return s.handleCounterfactualList(r, parts[1])
+	case EndpointCounterfactualCommitStatus:
+		return s.handleCommitStatus(parts[1])
+	case EndpointCounterfactualChain:
+		return s.handleCounterfactualChain(parts[1])
+	}
+	return &mockResponse{http.StatusNotFound, errorBody("ERR_NOT_FOUND", "no such endpoint")}
+}
+
+// handleCounterfactualIssue issues a v1 receipt, or with commit a v2
+// receipt that carries only a commitment to the decision reason.
+func (s *MockServer) handleCounterfactualIssue(r *http.Request, commit bool, corruptRate float64) *mockResponse {
+	var req struct {
+		ActionNotTaken         string         `json:"action_not_taken"`
+		CapabilityProof        map[string]any `json:"capability_proof"`
+		OpportunityContext     map[string]any `json:"opportunity_context"`
+		DecisionReason         string         `json:"decision_reason"`
+		DeclinationReason      string         `json:"declination_reason"`
+		ProvenanceRefs         []string       `json:"provenance_refs"`
+		ValidityWindowMinutes  int            `json:"validity_window_minutes"`
+		MinRevealDelaySeconds  int            `json:"min_reveal_delay_seconds"`
+		MaxRevealWindowSeconds int            `json:"max_reveal_window_seconds"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil || req.ActionNotTaken == "" || req.DecisionReason == "" {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody(notary.ErrValidationFailed, "action_not_taken and decision_reason are required")}
+	}
+	payload := map[string]any{
+		"action_not_taken":    req.ActionNotTaken,
+		"capability_proof":    req.CapabilityProof,
+		"opportunity_context": req.OpportunityContext,
+		"declination_reason":  req.DeclinationReason,
+	}
+	if !commit {
+		payload["decision_reason"] = req.DecisionReason
+	}
+	payloadHash := notary.ComputeHash(payload, notary.HashSHA256)
+	if payloadHash == "" {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody(notary.ErrValidationFailed, "payload is not canonicalizable")}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now().UTC()
+	s.nextID++
+	receipt := map[string]any{
+		"receipt_id":         fmt.Sprintf("cf_test_%06d", s.nextID),
+		"timestamp":          now.Format(time.RFC3339),
+		"agent_id":           s.agentID,
+		"action_type":        "counterfactual",
+		"payload_hash":       payloadHash,
+		"action_not_taken":   req.ActionNotTaken,
+		"declination_reason": req.DeclinationReason,
+		"valid_until":        now.Add(time.Duration(req.ValidityWindowMinutes) * time.Minute).Format(time.RFC3339),
+	}
+	if n := len(s.cf.order); n > 0 {
+		receipt["previous_receipt_hash"] = s.cf.order[n-1]
+	}
+	if len(req.ProvenanceRefs) > 0 {
+		receipt["provenance_refs"] = req.ProvenanceRefs
+	}
+	var c *mockCommit
+	if commit {
+		sum := sha256.Sum256([]byte(req.DecisionReason))
+		c = &mockCommit{
+			commitment:     hex.EncodeToString(sum[:]),
+			revealAfter:    now.Add(time.Duration(req.MinRevealDelaySeconds) * time.Second),
+			revealDeadline: now.Add(time.Duration(req.MinRevealDelaySeconds+req.MaxRevealWindowSeconds) * time.Second),
+		}
+		receipt["decision_commitment"] = c.commitment
+	}
+	if err := notary.SignReceipt(s.signer, receipt); err != nil {
+		return &mockResponse{http.StatusInternalServerError, errorBody(notary.ErrSigningError, err.Error())}
+	}
+	if s.rollLocked(corruptRate) {
+		corruptSignature(receipt)
+	}
+	hash := receiptHash(receipt)
+	s.cf.receipts[hash] = receipt
+	s.cf.order = append(s.cf.order, hash)
+	body := map[string]any{
+		"receipt":      receipt,
+		"receipt_hash": hash,
+		"verify_url":   s.URL + "/v1/notary/counterfactual/r/" + hash,
+	}
+	if c != nil {
+		s.cf.commits[hash] = c
+		body["commit_reveal"] = c.status(now)
+	}
+	return &mockResponse{http.StatusOK, body}
+}
+
+// status reports the commit-reveal phase at now.
+func (c *mockCommit) status(now time.Time) map[string]any {
+	phase := "committed"
+	switch {
+	case c.revealed:
+		phase = "revealed"
+	case now.After(c.revealDeadline):
+		phase = "expired"
+	case !now.Before(c.revealAfter):
+		phase = "reveal_open"
+	}
+	return map[string]any{
+		"phase":           phase,
+		"commitment":      c.commitment,
+		"reveal_after":    c.revealAfter.Format(time.RFC3339),
+		"reveal_deadline": c.revealDeadline.Format(time.RFC3339),
+	}
+}
+
+// handleReveal accepts the plaintext decision reason once the reveal
+// window opens, if it matches the commitment.
+func (s *MockServer) handleReveal(r *http.Request) *mockResponse {
+	var req struct {
+		ReceiptHash             string `json:"receipt_hash"`
+		DecisionReasonPlaintext string `json:"decision_reason_plaintext"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ReceiptHash == "" {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody(notary.ErrValidationFailed, "receipt_hash is required")}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.cf.commits[req.ReceiptHash]
+	if !ok {
+		return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "no commitment for receipt")}
+	}
+	now := s.now().UTC()
+	switch c.status(now)["phase"] {
+	case "committed":
+		return &mockResponse{http.StatusConflict, errorBody("ERR_REVEAL_TOO_EARLY", "reveal window has not opened")}
+	case "expired":
+		return &mockResponse{http.StatusConflict, errorBody("ERR_REVEAL_EXPIRED", "reveal window has closed")}
+	case "revealed":
+		return &mockResponse{http.StatusConflict, errorBody("ERR_ALREADY_REVEALED", "decision reason already revealed")}
+	}
+	sum := sha256.Sum256([]byte(req.DecisionReasonPlaintext))
+	if hex.EncodeToString(sum[:]) != c.commitment {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody("ERR_COMMITMENT_MISMATCH", "plaintext does not match commitment")}
+	}
+	c.revealed = true
+	return &mockResponse{http.StatusOK, map[string]any{
+		"receipt_hash":  req.ReceiptHash,
+		"revealed":      true,
+		"commit_reveal": c.status(now),
+	}}
+}
+
+func (s *MockServer) handleCommitStatus(hash string) *mockResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.cf.commits[hash]
+	if !ok {
+		return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "no commitment for receipt")}
+	}
+	status := c.status(s.now().UTC())
+	status["receipt_hash"] = hash
+	return &mockResponse{http.StatusOK, status}
+}
+
+func (s *MockServer) handleCorroborate(r *http.Request) *mockResponse {
+	var req struct {
+		ReceiptHash string   `json:"receipt_hash"`
+		Signals     []string `json:"corroboration_signals"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ReceiptHash == "" {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody(notary.ErrValidationFailed, "receipt_hash is required")}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cf.receipts[req.ReceiptHash]; !ok {
+		return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "receipt not found")}
+	}
+	s.cf.signals[req.ReceiptHash] = append(s.cf.signals[req.ReceiptHash], req.Signals...)
+	signals := s.cf.signals[req.ReceiptHash]
+	return &mockResponse{http.StatusOK, map[string]any{
+		"receipt_hash":          req.ReceiptHash,
+		"corroboration_count":   len(signals),
+		"corroboration_signals": signals,
+	}}
+}
+
+func (s *MockServer) handleCounterfactualGet(hash string, corruptRate float64) *mockResponse {
+	s.mu.Lock()
+	receipt, ok := s.cf.receipts[hash]
+	s.mu.Unlock()
+	if !ok {
+		return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "receipt not found")}
+	}
+	receipt = s.maybeCorrupt(receipt, corruptRate)
+	return &mockResponse{http.StatusOK, map[string]any{
+		"found":        true,
+		"receipt":      receipt,
+		"verification": s.verification(receipt),
+	}}
+}
+
+func (s *MockServer) handleCertificate(hash, format string) *mockResponse {
+	s.mu.Lock()
+	receipt, ok := s.cf.receipts[hash]
+	s.mu.Unlock()
+	if !ok {
+		return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "receipt not found")}
+	}
+	if format == "" {
+		format = "markdown"
+	}
+	v := s.verification(receipt)
+	certificate := fmt.Sprintf("# Counterfactual Certificate\n\nReceipt: %s\nAgent: %v\nAction not taken: %v\nIssued: %v\nSignature valid: %v\n",
+		hash, receipt["agent_id"], receipt["action_not_taken"], receipt["timestamp"], v["valid"])
+	return &mockResponse{http.StatusOK, map[string]any{
+		"receipt_hash": hash,
+		"format":       format,
+		"certificate":  certificate,
+		"verification": v,
+	}}
+}
+
+func (s *MockServer) handleCounterfactualList(r *http.Request, agentID string) *mockResponse {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit < 1 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []map[string]any
+	for _, h := range s.cf.order {
+		if receipt := s.cf.receipts[h]; receipt["agent_id"] == agentID {
+			matched = append(matched, receipt)
+		}
+	}
+	total := len(matched)
+	page := []map[string]any{}
+	if offset < total {
+		page = matched[offset:min(offset+limit, total)]
+	}
+	return &mockResponse{http.StatusOK, map[string]any{
+		"agent_id": agentID,
+		"receipts": page,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	}}
+}
+
+// handleCounterfactualChain checks that each of the agent's counterfactual
+// receipts links to the one issued before it.
+func (s *MockServer) handleCounterfactualChain(agentID string) *mockResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	breaks := []string{}
+	length := 0
+	prev := ""
+	for _, h := range s.cf.order {
+		receipt := s.cf.receipts[h]
+		if receipt["agent_id"] != agentID {
+			continue
+		}
+		link, _ := receipt["previous_receipt_hash"].(string)
+		if length > 0 && link != prev {
+			breaks = append(breaks, h)
+		}
+		prev = h
+		length++
+	}
+	return &mockResponse{http.StatusOK, map[string]any{
+		"agent_id":     agentID,
+		"valid":        len(breaks) == 0,
+		"chain_length": length,
+		"breaks":       breaks,
+	}}
+}
@@ -0,0 +1,59 @@
+// Package notarytest provides test helpers for code that uses the notary SDK.
+package notarytest
+
+import (
+	"sync"
+
+	"github.com/hellothere012/notaryos-go/notary"
+)
+
+// DryRunSink is an in-memory notary.DryRunSink for asserting exactly which
+// receipts would have been issued.
+//
+//	sink := &notarytest.DryRunSink{}
+//	notary.RecordAction(client, nil, "agent", "fn", args, result, nil, 1.5,
+//	    &notary.WrapConfig{DryRun: true, DryRunSink: sink})
+//	if got := sink.ActionTypes(); len(got) != 1 || got[0] != "fn" { ... }
+type DryRunSink struct {
+	mu      sync.Mutex
+	records []notary.DryRunRecord
+}
+
+// Record stores the record.
+func (s *DryRunSink) Record(rec notary.DryRunRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, rec)
+}
+
+// Records returns a copy of all records in arrival order.
+func (s *DryRunSink) Records() []notary.DryRunRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]notary.DryRunRecord(nil), s.records...)
+}
+
+// ActionTypes returns the action type of each record in arrival order.
+func (s *DryRunSink) ActionTypes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types := make([]string, len(s.records))
+	for i, r := range s.records {
+		types[i] = r.ActionType
+	}
+	return types
+}
+
+// Len returns the number of records.
+func (s *DryRunSink) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// Reset discards all records.
+func (s *DryRunSink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = nil
+}
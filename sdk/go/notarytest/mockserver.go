@@ -0,0 +1,763 @@
+package notarytest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hellothere012/notaryos-go/notary"
+)
+
+// Endpoint names for SetFault and Requests.
+const (
+	EndpointIssue      = "issue"
+	EndpointVerify     = "verify"
+	EndpointLookup     = "lookup"
+	EndpointHistory    = "history"
+	EndpointStatus     = "status"
+	EndpointPublicKey  = "public-key"
+	EndpointMe         = "me"
+	EndpointJWKS       = "jwks"
+	EndpointDeletions  = "deletions"
+	EndpointBloom      = "bloom"
+	EndpointLinks      = "links"      // create and resolve short links
+	EndpointKeys       = "keys"       // per-key metadata
+	EndpointProvenance = "provenance" // provenance DAG reports
+
+	EndpointCounterfactualIssue        = "counterfactual-issue"
+	EndpointCounterfactualGet          = "counterfactual-get"
+	EndpointCounterfactualList         = "counterfactual-list"
+	EndpointCounterfactualCommit       = "counterfactual-commit"
+	EndpointCounterfactualReveal       = "counterfactual-reveal"
+	EndpointCounterfactualCommitStatus = "counterfactual-commit-status"
+	EndpointCounterfactualCorroborate  = "counterfactual-corroborate"
+	EndpointCounterfactualCertificate  = "counterfactual-certificate"
+	EndpointCounterfactualChain        = "counterfactual-chain"
+
+	// EndpointAll applies a fault to every endpoint without its own.
+	EndpointAll = "*"
+)
+
+// Fault describes the failures an endpoint injects. Counted faults apply
+// to the next matching requests; rates draw from the server's seeded
+// source, so a given seed and request order always fail the same way.
+type Fault struct {
+	// Latency delays every response.
+	Latency time.Duration
+	// RateLimitBurst answers the next N requests with 429 and Retry-After.
+	RateLimitBurst int
+	// RetryAfter is the Retry-After sent with 429s, in whole seconds
+	// rounded down (default 1s). Under a second sends 0, which the client
+	// retries at once.
+	RetryAfter time.Duration
+	// FlapDown and FlapUp cycle the endpoint between FlapDown failing
+	// requests and FlapUp succeeding ones, starting down.
+	FlapDown int
+	FlapUp   int
+	// ServerErrorRate fails this fraction of requests (0..1).
+	ServerErrorRate float64
+	// ServerErrorStatus is the status of injected 5xx (default 503).
+	ServerErrorStatus int
+	// TruncateRate cuts this fraction of response bodies in half and drops
+	// the connection.
+	TruncateRate float64
+	// InvalidSignatureRate corrupts the signature of this fraction of the
+	// receipts the endpoint returns. Corrupted issued receipts are stored
+	// that way, so later verification agrees.
+	InvalidSignatureRate float64
+}
+
+// MockConfig configures a MockServer. The zero value is usable.
+type MockConfig struct {
+	// Signer signs issued receipts (default: a key derived from Seed).
+	Signer notary.Signer
+	// AgentID of issued receipts (default "agent-test").
+	AgentID string
+	// Seed drives the fault source and the default key.
+	Seed int64
+	// Now returns receipt timestamps (default time.Now).
+	Now func() time.Time
+}
+
+// MockServer is an in-process NotaryOS API for tests. It issues real
+// signed receipts (honoring Idempotency-Key), serves every endpoint the SDK
+// calls (lookup, history, verification, keys, short links, bloom snapshots,
+// provenance and counterfactuals), and injects per-endpoint faults so retry,
+// circuit-breaker and fallback logic can be exercised deterministically:
+//
+//	srv := notarytest.NewMockServer(nil)
+//	defer srv.Close()
+//	srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{RateLimitBurst: 2, RetryAfter: 10 * time.Millisecond})
+//	client, _ := notary.NewClient("notary_test_key", &notary.Config{BaseURL: srv.URL, MaxRetries: 2})
+type MockServer struct {
+	*httptest.Server
+
+	signer  notary.Signer
+	agentID string
+	now     func() time.Time
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	faults   map[string]*faultState
+	requests map[string]int
+	receipts map[string]map[string]any // receipt hash -> receipt
+	order    []string
+	head     string
+	nextID   int
+	issued   map[string]*mockResponse  // Idempotency-Key -> first response
+	metadata map[string]map[string]any // receipt hash -> issue metadata
+	links    map[string]*notary.ShortLink
+	cf       counterfactualState
+}
+
+type faultState struct {
+	Fault
+	flapPos int
+}
+
+// NewMockServer starts a MockServer. Close it when done.
+func NewMockServer(cfg *MockConfig) *MockServer {
+	if cfg == nil {
+		cfg = &MockConfig{}
+	}
+	s := &MockServer{
+		signer:   cfg.Signer,
+		agentID:  cfg.AgentID,
+		now:      cfg.Now,
+		rng:      rand.New(rand.NewSource(cfg.Seed)),
+		faults:   make(map[string]*faultState),
+		requests: make(map[string]int),
+		receipts: make(map[string]map[string]any),
+		issued:   make(map[string]*mockResponse),
+		metadata: make(map[string]map[string]any),
+		links:    make(map[string]*notary.ShortLink),
+		cf:       newCounterfactualState(),
+	}
+	if s.signer == nil {
+		var seed [8]byte
+		binary.BigEndian.PutUint64(seed[:], uint64(cfg.Seed))
+		key := sha256.Sum256(append([]byte("notarytest"), seed[:]...))
+		s.signer, _ = notary.NewKeySigner(ed25519.NewKeyFromSeed(key[:]), "")
+	}
+	if s.agentID == "" {
+		s.agentID = "agent-test"
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Signer returns the signer of issued receipts, for offline verification.
+func (s *MockServer) Signer() notary.Signer {
+	return s.signer
+}
+
+// SetFault replaces the faults injected by endpoint (or EndpointAll).
+func (s *MockServer) SetFault(endpoint string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[endpoint] = &faultState{Fault: f}
+}
+
+// ClearFaults removes every fault.
+func (s *MockServer) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[string]*faultState)
+}
+
+// Requests returns how many requests endpoint has received, including
+// those answered with an injected fault.
+func (s *MockServer) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// Receipts returns the stored receipts in issue order.
+func (s *MockServer) Receipts() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]map[string]any, 0, len(s.order))
+	for _, h := range s.order {
+		if r, ok := s.receipts[h]; ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func endpointFor(method, path string) string {
+	if cf, ok := strings.CutPrefix(path, "/v1/notary/counterfactual/"); ok {
+		return counterfactualEndpoint(method, cf)
+	}
+	switch {
+	case path == "/.well-known/jwks.json":
+		return EndpointJWKS
+	case method == "POST" && path == "/v1/notary/issue":
+		return EndpointIssue
+	case method == "POST" && path == "/v1/notary/verify":
+		return EndpointVerify
+	case method == "GET" && strings.HasPrefix(path, "/v1/notary/r/") && strings.HasSuffix(path, "/provenance"):
+		return EndpointProvenance
+	case method == "GET" && strings.HasPrefix(path, "/v1/notary/r/"):
+		return EndpointLookup
+	case method == "GET" && path == "/v1/notary/history":
+		return EndpointHistory
+	case method == "GET" && path == "/v1/notary/status":
+		return EndpointStatus
+	case method == "GET" && path == "/v1/notary/public-key":
+		return EndpointPublicKey
+	case method == "GET" && path == "/v1/notary/agents/me":
+		return EndpointMe
+	case method == "POST" && path == "/v1/notary/receipts/deletions":
+		return EndpointDeletions
+	case method == "GET" && path == "/v1/notary/bloom":
+		return EndpointBloom
+	case method == "POST" && path == "/v1/notary/links",
+		method == "GET" && strings.HasPrefix(path, "/v1/notary/links/"):
+		return EndpointLinks
+	case method == "GET" && strings.HasPrefix(path, "/v1/notary/keys/"):
+		return EndpointKeys
+	}
+	return ""
+}
+
+// mockResponse is a handler's result before faults shape delivery.
+type mockResponse struct {
+	status int
+	body   any
+}
+
+func (s *MockServer) serve(w http.ResponseWriter, r *http.Request) {
+	endpoint := endpointFor(r.Method, r.URL.Path)
+	if endpoint == "" {
+		writeJSON(w, http.StatusNotFound, errorBody("ERR_NOT_FOUND", "no such endpoint"), false)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests[endpoint]++
+	f := s.faults[endpoint]
+	if f == nil {
+		f = s.faults[EndpointAll]
+	}
+	var resp *mockResponse
+	var truncate bool
+	var latency time.Duration
+	var corruptRate float64
+	if f != nil {
+		latency = f.Latency
+		resp = s.injectLocked(f, w)
+		truncate = s.rollLocked(f.TruncateRate)
+		corruptRate = f.InvalidSignatureRate
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if resp == nil {
+		resp = s.handle(endpoint, r, corruptRate)
+	}
+	writeJSON(w, resp.status, resp.body, truncate)
+}
+
+// injectLocked returns the fault response for this request, if any.
+func (s *MockServer) injectLocked(f *faultState, w http.ResponseWriter) *mockResponse {
+	if f.RateLimitBurst > 0 {
+		f.RateLimitBurst--
+		retryAfter := f.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		return &mockResponse{http.StatusTooManyRequests, errorBody(notary.ErrRateLimitExceeded, "rate limit exceeded")}
+	}
+	status := f.ServerErrorStatus
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	if f.FlapDown > 0 {
+		pos := f.flapPos
+		f.flapPos = (f.flapPos + 1) % (f.FlapDown + f.FlapUp)
+		if pos < f.FlapDown {
+			return &mockResponse{status, errorBody("ERR_SERVICE_UNAVAILABLE", "injected flap")}
+		}
+	}
+	if s.rollLocked(f.ServerErrorRate) {
+		return &mockResponse{status, errorBody("ERR_SERVICE_UNAVAILABLE", "injected server error")}
+	}
+	return nil
+}
+
+// rollLocked reports whether a draw falls under rate. Callers hold s.mu.
+func (s *MockServer) rollLocked(rate float64) bool {
+	return rate > 0 && s.rng.Float64() < rate
+}
+
+// roll is rollLocked for callers that do not hold s.mu.
+func (s *MockServer) roll(rate float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rollLocked(rate)
+}
+
+func (s *MockServer) handle(endpoint string, r *http.Request, corruptRate float64) *mockResponse {
+	switch endpoint {
+	case EndpointIssue:
+		return s.handleIssue(r, corruptRate)
+	case EndpointVerify:
+		return s.handleVerify(r)
+	case EndpointLookup:
+		return s.handleLookup(strings.TrimPrefix(r.URL.Path, "/v1/notary/r/"), corruptRate)
+	case EndpointHistory:
+		return s.handleHistory(r, corruptRate)
+	case EndpointStatus:
+		return &mockResponse{http.StatusOK, map[string]any{
+			"status":         "active",
+			"signature_type": "ed25519",
+			"key_id":         s.signer.KeyID(),
+			"has_public_key": true,
+			"capabilities":   []string{"issue", "verify", "lookup", "history"},
+			"timestamp":      s.now().UTC().Format(time.RFC3339),
+		}}
+	case EndpointPublicKey:
+		der, _ := x509.MarshalPKIXPublicKey(s.signer.PublicKey())
+		return &mockResponse{http.StatusOK, map[string]any{
+			"key_id":         s.signer.KeyID(),
+			"signature_type": "ed25519",
+			"public_key_pem": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		}}
+	case EndpointMe:
+		return &mockResponse{http.StatusOK, map[string]any{
+			"agent_id":              s.agentID,
+			"agent_name":            s.agentID,
+			"tier":                  "test",
+			"scopes":                []string{"issue:write"},
+			"rate_limit_per_minute": 0,
+		}}
+	case EndpointJWKS:
+		return &mockResponse{http.StatusOK, map[string]any{"keys": []map[string]any{s.jwk()}}}
+	case EndpointDeletions:
+		return s.handleDeletions(r)
+	case EndpointBloom:
+		return s.handleBloom()
+	case EndpointLinks:
+		return s.handleLinks(r)
+	case EndpointKeys:
+		return s.handleKey(strings.TrimPrefix(r.URL.Path, "/v1/notary/keys/"))
+	case EndpointProvenance:
+		hash := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/notary/r/"), "/provenance")
+		return s.handleProvenance(hash)
+	}
+	if strings.HasPrefix(endpoint, "counterfactual-") {
+		return s.handleCounterfactual(endpoint, r, corruptRate)
+	}
+	return &mockResponse{http.StatusNotFound, errorBody("ERR_NOT_FOUND", "no such endpoint")}
+}
+
+func (s *MockServer) handleIssue(r *http.Request, corruptRate float64) *mockResponse {
+	var req struct {
+		ActionType          string            `json:"action_type"`
+		Payload             map[string]any    `json:"payload"`
+		PreviousReceiptHash string            `json:"previous_receipt_hash"`
+		HashAlgorithm       string            `json:"hash_algorithm"`
+		Metadata            map[string]any    `json:"metadata"`
+		Tags                []string          `json:"tags"`
+		IndexedFields       map[string]string `json:"indexed_fields"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil || req.ActionType == "" {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody(notary.ErrValidationFailed, "action_type is required")}
+	}
+	payloadHash := notary.ComputeHash(req.Payload, notary.HashAlgorithm(req.HashAlgorithm))
+	if payloadHash == "" {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody("ERR_UNSUPPORTED_HASH", "unsupported hash algorithm")}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := r.Header.Get("Idempotency-Key")
+	if prior, ok := s.issued[key]; ok && key != "" {
+		return prior
+	}
+	s.nextID++
+	receipt := map[string]any{
+		"receipt_id":     fmt.Sprintf("rcpt_test_%06d", s.nextID),
+		"timestamp":      s.now().UTC().Format(time.RFC3339),
+		"agent_id":       s.agentID,
+		"action_type":    req.ActionType,
+		"payload_hash":   payloadHash,
+		"chain_sequence": s.nextID,
+	}
+	prev := req.PreviousReceiptHash
+	if prev == "" {
+		prev = s.head
+	}
+	if prev != "" {
+		receipt["previous_receipt_hash"] = prev
+	}
+	if len(req.Tags) > 0 {
+		receipt["tags"] = req.Tags
+	}
+	if len(req.IndexedFields) > 0 {
+		receipt["indexed_fields"] = req.IndexedFields
+	}
+	if err := notary.SignReceipt(s.signer, receipt); err != nil {
+		return &mockResponse{http.StatusInternalServerError, errorBody(notary.ErrSigningError, err.Error())}
+	}
+	if s.rollLocked(corruptRate) {
+		corruptSignature(receipt)
+	}
+	hash := receiptHash(receipt)
+	s.receipts[hash] = receipt
+	s.order = append(s.order, hash)
+	s.head = hash
+	if req.Metadata != nil {
+		s.metadata[hash] = req.Metadata
+	}
+	resp := &mockResponse{http.StatusOK, map[string]any{
+		"receipt":        receipt,
+		"receipt_hash":   hash,
+		"verify_url":     s.URL + "/v1/notary/r/" + hash,
+		"chain_position": len(s.order),
+	}}
+	if key != "" {
+		s.issued[key] = resp
+	}
+	return resp
+}
+
+func (s *MockServer) handleVerify(r *http.Request) *mockResponse {
+	var req struct {
+		Receipt   map[string]any `json:"receipt"`
+		ReceiptID string         `json:"receipt_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody(notary.ErrValidationFailed, "invalid request body")}
+	}
+	receipt := req.Receipt
+	if receipt == nil && req.ReceiptID != "" {
+		s.mu.Lock()
+		for _, stored := range s.receipts {
+			if stored["receipt_id"] == req.ReceiptID {
+				receipt = stored
+				break
+			}
+		}
+		s.mu.Unlock()
+		if receipt == nil {
+			return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "receipt not found")}
+		}
+	}
+	return &mockResponse{http.StatusOK, s.verification(receipt)}
+}
+
+func (s *MockServer) verification(receipt map[string]any) map[string]any {
+	var v notary.OfflineVerifier
+	v.TrustSigner(s.signer)
+	result := v.Verify(receipt)
+	return map[string]any{
+		"valid":        result.Valid,
+		"signature_ok": result.SignatureOK,
+		"structure_ok": result.StructureOK,
+		"reason":       result.Reason,
+		"details":      map[string]any{"key_id": result.KeyID},
+	}
+}
+
+func (s *MockServer) handleLookup(hash string, corruptRate float64) *mockResponse {
+	s.mu.Lock()
+	receipt, ok := s.receipts[hash]
+	s.mu.Unlock()
+	if !ok {
+		return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "receipt not found")}
+	}
+	receipt = s.maybeCorrupt(receipt, corruptRate)
+	return &mockResponse{http.StatusOK, map[string]any{
+		"found":        true,
+		"receipt":      receipt,
+		"verification": s.verification(receipt),
+	}}
+}
+
+func (s *MockServer) handleHistory(r *http.Request, corruptRate float64) *mockResponse {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	start, _ := time.Parse(time.RFC3339, q.Get("start_date"))
+	end, _ := time.Parse(time.RFC3339, q.Get("end_date"))
+
+	type entry struct {
+		hash    string
+		receipt map[string]any
+	}
+	var matched []entry
+	s.mu.Lock()
+	for _, h := range s.order {
+		receipt, ok := s.receipts[h]
+		if !ok {
+			continue
+		}
+		ts, _ := time.Parse(time.RFC3339, receipt["timestamp"].(string))
+		if (!start.IsZero() && ts.Before(start)) || (!end.IsZero() && ts.After(end)) {
+			continue
+		}
+		if agent := q.Get("agent_id"); agent != "" && receipt["agent_id"] != agent {
+			continue
+		}
+		if !matchesIndex(receipt, q) {
+			continue
+		}
+		matched = append(matched, entry{h, receipt})
+	}
+	s.mu.Unlock()
+
+	items := []map[string]any{}
+	for i := (page - 1) * pageSize; i < len(matched) && i < page*pageSize; i++ {
+		items = append(items, map[string]any{
+			"receipt_hash": matched[i].hash,
+			"receipt":      s.maybeCorrupt(matched[i].receipt, corruptRate),
+		})
+	}
+	return &mockResponse{http.StatusOK, map[string]any{
+		"items":      items,
+		"total":      len(matched),
+		"totalPages": (len(matched) + pageSize - 1) / pageSize,
+		"page":       page,
+		"pageSize":   pageSize,
+	}}
+}
+
+func (s *MockServer) handleDeletions(r *http.Request) *mockResponse {
+	var req struct {
+		ReceiptHashes      []string `json:"receipt_hashes"`
+		ArchiveReceiptHash string   `json:"archive_receipt_hash"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ArchiveReceiptHash == "" {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody(notary.ErrValidationFailed, "archive_receipt_hash is required")}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	marked := 0
+	for _, h := range req.ReceiptHashes {
+		if _, ok := s.receipts[h]; ok && h != req.ArchiveReceiptHash {
+			delete(s.receipts, h)
+			marked++
+		}
+	}
+	return &mockResponse{http.StatusOK, map[string]any{"marked": marked}}
+}
+
+func (s *MockServer) handleBloom() *mockResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filter := notary.NewBloomFilter(len(s.receipts), 0.01)
+	for h := range s.receipts {
+		filter.Add(h)
+	}
+	return &mockResponse{http.StatusOK, filter}
+}
+
+func (s *MockServer) handleLinks(r *http.Request) *mockResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method == http.MethodGet {
+		link, ok := s.links[strings.TrimPrefix(r.URL.Path, "/v1/notary/links/")]
+		if !ok {
+			return &mockResponse{http.StatusNotFound, errorBody("ERR_NOT_FOUND", "short link not found")}
+		}
+		return &mockResponse{http.StatusOK, link}
+	}
+
+	var req struct {
+		ReceiptHash   string `json:"receipt_hash"`
+		ExpiresInDays int    `json:"expires_in_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ReceiptHash == "" {
+		return &mockResponse{http.StatusUnprocessableEntity, errorBody(notary.ErrValidationFailed, "receipt_hash is required")}
+	}
+	if _, ok := s.receipts[req.ReceiptHash]; !ok {
+		return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "receipt not found")}
+	}
+	code := fmt.Sprintf("t%05d", len(s.links)+1)
+	now := s.now().UTC()
+	link := &notary.ShortLink{
+		Code:        code,
+		ShortURL:    s.URL + "/s/" + code,
+		ReceiptHash: req.ReceiptHash,
+		TargetURL:   s.URL + "/v1/notary/r/" + req.ReceiptHash,
+		CreatedAt:   now.Format(time.RFC3339),
+	}
+	if req.ExpiresInDays > 0 {
+		link.ExpiresAt = now.AddDate(0, 0, req.ExpiresInDays).Format(time.RFC3339)
+	}
+	s.links[code] = link
+	return &mockResponse{http.StatusOK, link}
+}
+
+func (s *MockServer) handleKey(kid string) *mockResponse {
+	if kid != s.signer.KeyID() {
+		return &mockResponse{http.StatusNotFound, errorBody("ERR_NOT_FOUND", "key not found")}
+	}
+	return &mockResponse{http.StatusOK, s.jwk()}
+}
+
+// jwk is the signer's public key as served in the JWKS.
+func (s *MockServer) jwk() map[string]any {
+	return map[string]any{
+		"kty": "OKP", "crv": "Ed25519", "alg": "EdDSA", "use": "sig",
+		"kid":    s.signer.KeyID(),
+		"x":      base64.RawURLEncoding.EncodeToString(s.signer.PublicKey()),
+		"status": notary.KeyStatusActive,
+	}
+}
+
+// handleProvenance reports the receipts reachable from hash through
+// metadata.provenance_refs, with one edge per reference.
+func (s *MockServer) handleProvenance(hash string) *mockResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.receipts[hash]; !ok {
+		return &mockResponse{http.StatusNotFound, errorBody(notary.ErrReceiptNotFound, "receipt not found")}
+	}
+	nodes := []map[string]any{}
+	edges := []map[string]any{}
+	seen := map[string]bool{hash: true}
+	queue := []string{hash}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		receipt, ok := s.receipts[h]
+		node := map[string]any{"receipt_hash": h, "found": ok}
+		if ok {
+			node["action_type"] = receipt["action_type"]
+			node["agent_id"] = receipt["agent_id"]
+			node["timestamp"] = receipt["timestamp"]
+		}
+		nodes = append(nodes, node)
+		refs, _ := s.metadata[h]["provenance_refs"].([]any)
+		for _, ref := range refs {
+			parent, _ := ref.(string)
+			if parent == "" {
+				continue
+			}
+			edges = append(edges, map[string]any{"from": parent, "to": h})
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return &mockResponse{http.StatusOK, map[string]any{
+		"receipt_hash": hash,
+		"nodes":        nodes,
+		"edges":        edges,
+	}}
+}
+
+// matchesIndex applies the history tag and field.* filters.
+func matchesIndex(receipt map[string]any, q url.Values) bool {
+	tags, _ := receipt["tags"].([]string)
+	for _, want := range q["tag"] {
+		found := false
+		for _, tag := range tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	fields, _ := receipt["indexed_fields"].(map[string]string)
+	for key, values := range q {
+		if name, ok := strings.CutPrefix(key, "field."); ok && fields[name] != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// maybeCorrupt returns receipt, or a copy with a corrupted signature.
+func (s *MockServer) maybeCorrupt(receipt map[string]any, rate float64) map[string]any {
+	if !s.roll(rate) {
+		return receipt
+	}
+	cp := make(map[string]any, len(receipt))
+	for k, v := range receipt {
+		cp[k] = v
+	}
+	corruptSignature(cp)
+	return cp
+}
+
+// corruptSignature flips a bit of the signature so it no longer verifies.
+func corruptSignature(receipt map[string]any) {
+	sig, err := base64.StdEncoding.DecodeString(receipt["signature"].(string))
+	if err != nil || len(sig) == 0 {
+		return
+	}
+	sig[0] ^= 0x01
+	receipt["signature"] = base64.StdEncoding.EncodeToString(sig)
+}
+
+func receiptHash(receipt map[string]any) string {
+	data, _ := notary.CanonicalJSON(receipt)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func errorBody(code, message string) map[string]any {
+	return map[string]any{"error": map[string]any{"code": code, "message": message}}
+}
+
+// writeJSON writes body, or when truncate is set, half of it before
+// dropping the connection.
+func writeJSON(w http.ResponseWriter, status int, body any, truncate bool) {
+	var buf bytes.Buffer
+	_ = json.NewEncoder(&buf).Encode(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	if !truncate {
+		w.Write(buf.Bytes())
+		return
+	}
+	w.Write(buf.Bytes()[:buf.Len()/2])
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,202 @@
+package notarytest_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hellothere012/notaryos-go/notary"
+	"github.com/hellothere012/notaryos-go/notarytest"
+)
+
+func newClient(t *testing.T, srv *notarytest.MockServer, config notary.Config) *notary.Client {
+	t.Helper()
+	config.BaseURL = srv.URL
+	client, err := notary.NewClient("notary_test_key", &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func errorCode(err error) string {
+	var nerr *notary.NotaryError
+	if errors.As(err, &nerr) {
+		return nerr.Code
+	}
+	return ""
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	srv := notarytest.NewMockServer(nil)
+	defer srv.Close()
+
+	// A sub-second Retry-After is sent as 0, which the client retries at once.
+	srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{RateLimitBurst: 2, RetryAfter: time.Millisecond})
+	if _, err := newClient(t, srv, notary.Config{MaxRetries: 2}).Issue("billing.charge", nil); err != nil {
+		t.Fatalf("Issue through a burst of two 429s: %v", err)
+	}
+	if n := srv.Requests(notarytest.EndpointIssue); n != 3 {
+		t.Errorf("Issue requests = %d, want 3", n)
+	}
+
+	// Out of retries, the 429 surfaces with its metadata.
+	srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{RateLimitBurst: 2, RetryAfter: 3 * time.Second})
+	_, err := newClient(t, srv, notary.Config{MaxRetries: 0}).Issue("billing.charge", nil)
+	var nerr *notary.NotaryError
+	if !errors.As(err, &nerr) || nerr.Status != 429 || nerr.Code != notary.ErrRateLimitExceeded {
+		t.Fatalf("Issue error = %v, want a 429", err)
+	}
+	if nerr.Meta == nil || nerr.Meta.RetryAfter != 3*time.Second {
+		t.Errorf("RetryAfter = %+v, want 3s", nerr.Meta)
+	}
+
+	// A whole-second Retry-After is waited out, not the 5s fallback.
+	srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{RateLimitBurst: 1, RetryAfter: time.Second})
+	start := time.Now()
+	if _, err := newClient(t, srv, notary.Config{MaxRetries: 1}).Issue("billing.charge", nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed >= 5*time.Second {
+		t.Errorf("retry after %v, want the server's 1s", elapsed)
+	}
+}
+
+func TestFlapRetry(t *testing.T) {
+	srv := notarytest.NewMockServer(nil)
+	defer srv.Close()
+	srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{FlapDown: 1, FlapUp: 1, ServerErrorStatus: 502})
+
+	client := newClient(t, srv, notary.Config{MaxRetries: 0})
+	_, err := client.Issue("billing.charge", nil)
+	var nerr *notary.NotaryError
+	if !errors.As(err, &nerr) || nerr.Status != 502 {
+		t.Fatalf("first Issue error = %v, want a 502", err)
+	}
+	if _, err := client.Issue("billing.charge", nil); err != nil {
+		t.Fatalf("second Issue (flap up): %v", err)
+	}
+
+	// With a retry, the down phase is absorbed.
+	if _, err := newClient(t, srv, notary.Config{MaxRetries: 1}).Issue("billing.charge", nil); err != nil {
+		t.Fatalf("Issue with a retry: %v", err)
+	}
+	if n := srv.Requests(notarytest.EndpointIssue); n != 4 {
+		t.Errorf("Issue requests = %d, want 4", n)
+	}
+}
+
+func TestFlapOpensBreaker(t *testing.T) {
+	srv := notarytest.NewMockServer(nil)
+	defer srv.Close()
+	srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{FlapDown: 2, FlapUp: 10})
+	client := newClient(t, srv, notary.Config{
+		MaxRetries: 0,
+		Transport:  notary.TransportConfig{BreakerThreshold: 2, BreakerCooldown: 50 * time.Millisecond},
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Issue("billing.charge", nil); err == nil {
+			t.Fatalf("Issue %d succeeded during the down phase", i)
+		}
+	}
+	if state := client.BreakerState(); state != notary.BreakerOpen {
+		t.Fatalf("breaker %s after two failures, want open", state)
+	}
+	if _, err := client.Issue("billing.charge", nil); errorCode(err) != "ERR_CIRCUIT_OPEN" {
+		t.Fatalf("Issue with the breaker open = %v, want ERR_CIRCUIT_OPEN", err)
+	}
+	if n := srv.Requests(notarytest.EndpointIssue); n != 2 {
+		t.Errorf("open breaker let a request through: %d requests", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if state := client.BreakerState(); state != notary.BreakerHalfOpen {
+		t.Fatalf("breaker %s after the cooldown, want half-open", state)
+	}
+	if _, err := client.Issue("billing.charge", nil); err != nil {
+		t.Fatalf("trial Issue: %v", err)
+	}
+	if state := client.BreakerState(); state != notary.BreakerClosed {
+		t.Errorf("breaker %s after a successful trial, want closed", state)
+	}
+}
+
+func TestTruncatedResponse(t *testing.T) {
+	srv := notarytest.NewMockServer(nil)
+	defer srv.Close()
+	srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{TruncateRate: 1})
+
+	_, err := newClient(t, srv, notary.Config{MaxRetries: 2}).Issue("billing.charge", nil)
+	if code := errorCode(err); code != "ERR_READ" {
+		t.Fatalf("Issue error = %v, want ERR_READ", err)
+	}
+	// The body was cut after the server stored the receipt, and the client
+	// does not retry a read failure.
+	if n := srv.Requests(notarytest.EndpointIssue); n != 1 {
+		t.Errorf("Issue requests = %d, want 1", n)
+	}
+	if n := len(srv.Receipts()); n != 1 {
+		t.Errorf("server stored %d receipts, want 1", n)
+	}
+}
+
+func TestInvalidSignature(t *testing.T) {
+	srv := notarytest.NewMockServer(nil)
+	defer srv.Close()
+	srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{InvalidSignatureRate: 1})
+	client := newClient(t, srv, notary.Config{})
+
+	receipt, err := client.Issue("billing.charge", map[string]any{"amount": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verifier notary.OfflineVerifier
+	verifier.TrustSigner(srv.Signer())
+	if result := verifier.Verify(receipt.Raw); result.Valid || result.ReasonCode != notary.ReasonSignatureMismatch {
+		t.Errorf("offline verification = %+v, want a signature mismatch", result)
+	}
+	result, err := client.Verify(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid {
+		t.Error("server verified a corrupted receipt")
+	}
+}
+
+// TestSeedDeterminism runs the same calls against two servers: the same
+// seed gives the same failures and the same receipts, another seed does not.
+func TestSeedDeterminism(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := func(seed int64) (outcomes []string, hashes []string) {
+		srv := notarytest.NewMockServer(&notarytest.MockConfig{Seed: seed, Now: func() time.Time { return now }})
+		defer srv.Close()
+		srv.SetFault(notarytest.EndpointIssue, notarytest.Fault{ServerErrorRate: 0.5})
+		client := newClient(t, srv, notary.Config{MaxRetries: 0})
+		for i := 0; i < 24; i++ {
+			receipt, err := client.Issue("billing.charge", map[string]any{"n": i})
+			if err != nil {
+				outcomes = append(outcomes, errorCode(err))
+				continue
+			}
+			outcomes = append(outcomes, "ok")
+			hashes = append(hashes, receipt.ReceiptHash)
+		}
+		return outcomes, hashes
+	}
+
+	first, firstHashes := run(7)
+	second, secondHashes := run(7)
+	if !reflect.DeepEqual(first, second) || !reflect.DeepEqual(firstHashes, secondHashes) {
+		t.Fatalf("seed 7 differs between runs:\n%v\n%v", first, second)
+	}
+	if len(firstHashes) == 0 || len(firstHashes) == len(first) {
+		t.Fatalf("ServerErrorRate 0.5 failed %d of %d calls", len(first)-len(firstHashes), len(first))
+	}
+	other, otherHashes := run(8)
+	if reflect.DeepEqual(first, other) || reflect.DeepEqual(firstHashes, otherHashes) {
+		t.Error("seeds 7 and 8 produced the same run")
+	}
+}
@@ -0,0 +1,190 @@
+#!/usr/bin/env python3
+"""Regenerate the expected values in vectors.json and bloom.json.
+
+For each vector, canonical is payload_json as the Go SDK has always hashed
+it: encoding/json output for the value a Go caller would hold (integer
+literals that fit int64 as int64, other numbers as float64), with sorted
+keys. go_marshal below re-implements those encoding/json rules in Python,
+so the expected values do not come from the Go code under test. sha256 is
+the SHA-256 hex digest of canonical.
+
+For each bloom filter, bits is the filter after adding every hash in added,
+using the bit-position scheme documented on notary.BloomFilter, and absent
+lists probe hashes the filter rejects. Pass --check to fail instead of
+rewriting when a file is out of date.
+"""
+
+import base64
+import decimal
+import hashlib
+import json
+import math
+import os
+import re
+import sys
+
+DIR = os.path.dirname(os.path.abspath(__file__))
+PATH = os.path.join(DIR, "vectors.json")
+BLOOM_PATH = os.path.join(DIR, "bloom.json")
+MASK64 = (1 << 64) - 1
+HEX = "0123456789abcdef"
+
+
+class Number(str):
+    """A JSON number literal, kept verbatim until it is typed."""
+
+
+def go_float(f):
+    # encoding/json floatEncoder: shortest digits, 'f' format unless the
+    # magnitude is below 1e-6 or at least 1e21, and "e-07" trimmed to "e-7".
+    if math.isinf(f) or math.isnan(f):
+        raise ValueError("unsupported float %r" % f)
+    sign, digits, exp = decimal.Decimal(repr(f)).as_tuple()
+    digits = "".join(map(str, digits)).lstrip("0")
+    while digits.endswith("0"):
+        digits, exp = digits[:-1], exp + 1
+    neg = "-" if sign else ""
+    if not digits:
+        return neg + "0"
+    a = abs(f)
+    if a < 1e-6 or a >= 1e21:
+        sci = exp + len(digits) - 1
+        mant = digits[0] + ("." + digits[1:] if len(digits) > 1 else "")
+        if sci < 0:
+            return "%s%se-%d" % (neg, mant, -sci)
+        return "%s%se+%02d" % (neg, mant, sci)
+    if exp >= 0:
+        return neg + digits + "0" * exp
+    point = len(digits) + exp
+    if point <= 0:
+        return neg + "0." + "0" * -point + digits
+    return neg + digits[:point] + "." + digits[point:]
+
+
+def go_number(literal):
+    if re.fullmatch(r"-?\d+", literal) and -(1 << 63) <= int(literal) < (1 << 63):
+        return str(int(literal))
+    return go_float(float(literal))
+
+
+def go_string(s):
+    # encoding/json encodeString with HTML escaping (Go 1.22+ writes \b
+    # and \f as short escapes).
+    out = ['"']
+    for ch in s:
+        c = ord(ch)
+        if ch in '"\\':
+            out.append("\\" + ch)
+        elif ch in "\b\f\n\r\t":
+            out.append({"\b": "\\b", "\f": "\\f", "\n": "\\n", "\r": "\\r", "\t": "\\t"}[ch])
+        elif c < 0x20 or ch in "<>&":
+            out.append("\\u00" + HEX[c >> 4] + HEX[c & 0xF])
+        elif ch in "\u2028\u2029":
+            out.append("\\u%04x" % c)
+        else:
+            out.append(ch)
+    out.append('"')
+    return "".join(out)
+
+
+def go_marshal(v):
+    if v is None:
+        return "null"
+    if v is True:
+        return "true"
+    if v is False:
+        return "false"
+    if isinstance(v, Number):
+        return go_number(v)
+    if isinstance(v, str):
+        return go_string(v)
+    if isinstance(v, list):
+        return "[" + ",".join(go_marshal(x) for x in v) + "]"
+    # Go sorts keys by UTF-8 bytes, which is code point order.
+    return "{" + ",".join(go_string(k) + ":" + go_marshal(v[k]) for k in sorted(v)) + "}"
+
+
+def canonical(payload_json):
+    payload = json.loads(payload_json, parse_int=Number, parse_float=Number, parse_constant=Number)
+    return go_marshal(payload)
+
+
+def bloom_positions(receipt_hash, m, k):
+    receipt_hash = receipt_hash.strip().lower()
+    try:
+        digest = bytes.fromhex(receipt_hash)
+    except ValueError:
+        digest = b""
+    if len(digest) != 32:
+        digest = hashlib.sha256(receipt_hash.encode("utf-8")).digest()
+    h1 = int.from_bytes(digest[0:8], "big")
+    h2 = int.from_bytes(digest[8:16], "big") | 1
+    # The Go SDK computes h1 + i*h2 in wrapping uint64 arithmetic.
+    return [((h1 + i * h2) & MASK64) % m for i in range(k)]
+
+
+def bloom_bits(added, m, k):
+    bits = bytearray((m + 7) // 8)
+    for receipt_hash in added:
+        for bit in bloom_positions(receipt_hash, m, k):
+            bits[bit // 8] |= 1 << (bit % 8)
+    return bits
+
+
+def may_exist(bits, receipt_hash, m, k):
+    return all(bits[bit // 8] & (1 << (bit % 8)) for bit in bloom_positions(receipt_hash, m, k))
+
+
+def update_bloom(data):
+    stale = []
+    for bloom in data["filters"]:
+        m, k = bloom["m"], bloom["k"]
+        bits = bloom_bits(bloom["added"], m, k)
+        absent = []
+        i = 0
+        while len(absent) < 4:
+            probe = hashlib.sha256(("absent-%d" % i).encode()).hexdigest()
+            if not may_exist(bits, probe, m, k):
+                absent.append(probe)
+            i += 1
+        encoded = base64.b64encode(bytes(bits)).decode("ascii")
+        if bloom.get("bits") != encoded or bloom.get("absent") != absent:
+            stale.append(bloom["name"])
+        bloom["bits"] = encoded
+        bloom["absent"] = absent
+    return stale
+
+
+def write(path, data):
+    with open(path, "w", encoding="utf-8") as f:
+        json.dump(data, f, indent=2, ensure_ascii=False)
+        f.write("\n")
+
+
+def main():
+    with open(PATH, encoding="utf-8") as f:
+        data = json.load(f)
+    with open(BLOOM_PATH, encoding="utf-8") as f:
+        bloom = json.load(f)
+
+    stale = []
+    for vector in data["vectors"]:
+        text = canonical(vector["payload_json"])
+        digest = hashlib.sha256(text.encode("utf-8")).hexdigest()
+        if vector.get("canonical") != text or vector.get("sha256") != digest:
+            stale.append(vector["name"])
+        vector["canonical"] = text
+        vector["sha256"] = digest
+    stale += update_bloom(bloom)
+
+    if "--check" in sys.argv[1:]:
+        if stale:
+            sys.exit("stale vectors: " + ", ".join(stale))
+        return
+
+    write(PATH, data)
+    write(BLOOM_PATH, bloom)
+
+
+if __name__ == "__main__":
+    main()
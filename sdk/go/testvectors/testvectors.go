@@ -0,0 +1,242 @@
+// Package testvectors embeds the Go SDK's payload hash regression vectors
+// and bloom filter vectors, and checks that this build reproduces them byte
+// for byte. Run it in CI so a Go upgrade that changes encoding/json output,
+// and with it every payload hash, fails before it ships:
+//
+//	if err := testvectors.CheckCompat(); err != nil {
+//	    t.Fatal(err)
+//	}
+//
+// These are not an official cross-SDK set: the server publishes no vectors.
+// The payloads in vectors.json were written by hand to cover key ordering,
+// escaping and number formatting. Their expected canonical forms pin the
+// encoding/json serialization ComputeHash has always hashed, and are
+// computed by generate.py, a separate Python implementation of those
+// encoding rules, rather than by the Go code under test. Run
+// "python3 generate.py" after adding a payload and "python3 generate.py
+// --check" to confirm the files are current. The escapes_bs_ff vector
+// follows Go 1.22 and later, which write \b and \f as short escapes; older
+// toolchains fail it, and their hashes differ for such payloads.
+//
+// bloom.json holds filters built by generate.py from the bit-position
+// scheme documented on notary.BloomFilter, so a change to that scheme
+// shows up as a mismatch against an independent implementation.
+package testvectors
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hellothere012/notaryos-go/notary"
+)
+
+//go:embed vectors.json
+var vectorsJSON []byte
+
+//go:embed bloom.json
+var bloomJSON []byte
+
+// Vector is one payload with its expected canonical form and hash.
+type Vector struct {
+	Name string `json:"name"`
+	// PayloadJSON is the payload as a Go caller would build it: integer
+	// literals that fit int64 are int64, other numbers float64.
+	PayloadJSON string `json:"payload_json"`
+	// Canonical is the serialization ComputeHash hashes.
+	Canonical string `json:"canonical"`
+	// SHA256 is the hex digest of Canonical.
+	SHA256 string `json:"sha256"`
+}
+
+type vectorSet struct {
+	Version int      `json:"version"`
+	Vectors []Vector `json:"vectors"`
+}
+
+// Vectors returns the embedded vector set.
+func Vectors() []Vector {
+	var set vectorSet
+	if err := json.Unmarshal(vectorsJSON, &set); err != nil {
+		panic("testvectors: malformed vectors.json: " + err.Error())
+	}
+	return set.Vectors
+}
+
+// Mismatch describes a vector the SDK does not reproduce.
+type Mismatch struct {
+	Name          string
+	WantCanonical string
+	GotCanonical  string
+	WantHash      string
+	GotHash       string
+}
+
+// CompatError lists every vector that failed.
+type CompatError struct {
+	Mismatches []Mismatch
+}
+
+func (e *CompatError) Error() string {
+	names := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		names[i] = m.Name
+	}
+	first := e.Mismatches[0]
+	return fmt.Sprintf("notary hash compat: %d vector(s) failed (%s); %s: want %s, got %s",
+		len(e.Mismatches), strings.Join(names, ", "), first.Name, first.WantCanonical, first.GotCanonical)
+}
+
+// BloomVector is a bloom filter with its expected bits after adding Added.
+type BloomVector struct {
+	Name  string   `json:"name"`
+	M     uint64   `json:"m"`
+	K     int      `json:"k"`
+	Added []string `json:"added"`
+	// Bits is the base64 filter after adding every hash in Added.
+	Bits string `json:"bits"`
+	// Absent are hashes the filter must reject.
+	Absent []string `json:"absent"`
+}
+
+// BloomVectors returns the embedded bloom filter vectors.
+func BloomVectors() []BloomVector {
+	var set struct {
+		Filters []BloomVector `json:"filters"`
+	}
+	if err := json.Unmarshal(bloomJSON, &set); err != nil {
+		panic("testvectors: malformed bloom.json: " + err.Error())
+	}
+	return set.Filters
+}
+
+// CheckCompat hashes every vector with notary.ComputeHash and returns a
+// *CompatError if any canonical form or hash differs from the expected one.
+// It then checks the bloom filter vectors.
+func CheckCompat() error {
+	vectors := Vectors()
+	if len(vectors) == 0 {
+		return fmt.Errorf("testvectors: vectors.json holds no vectors")
+	}
+	var failed []Mismatch
+	for _, v := range vectors {
+		payload, err := decodePayload(v.PayloadJSON)
+		if err != nil {
+			return fmt.Errorf("testvectors: vector %s: %w", v.Name, err)
+		}
+		data, err := notary.CanonicalPayload(payload)
+		if err != nil {
+			return fmt.Errorf("testvectors: vector %s: %w", v.Name, err)
+		}
+		canonical := string(data)
+		hash := notary.ComputeHash(payload)
+		if canonical != v.Canonical || hash != v.SHA256 {
+			failed = append(failed, Mismatch{
+				Name:          v.Name,
+				WantCanonical: v.Canonical,
+				GotCanonical:  canonical,
+				WantHash:      v.SHA256,
+				GotHash:       hash,
+			})
+		}
+	}
+	if len(failed) > 0 {
+		return &CompatError{Mismatches: failed}
+	}
+	return checkBloom()
+}
+
+// decodePayload decodes a vector payload into the Go values a caller would
+// pass to ComputeHash.
+func decodePayload(payloadJSON string) (map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(payloadJSON))
+	dec.UseNumber()
+	var payload map[string]any
+	if err := dec.Decode(&payload); err != nil {
+		return nil, err
+	}
+	v, err := typeNumbers(payload)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]any), nil
+}
+
+func typeNumbers(v any) (any, error) {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, nil
+		}
+		return val.Float64()
+	case []any:
+		for i, item := range val {
+			typed, err := typeNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = typed
+		}
+	case map[string]any:
+		for k, item := range val {
+			typed, err := typeNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = typed
+		}
+	}
+	return v, nil
+}
+
+// checkBloom adds each vector's hashes to an empty notary.BloomFilter of
+// the same shape and compares the resulting bits and membership answers.
+func checkBloom() error {
+	vectors := BloomVectors()
+	if len(vectors) == 0 {
+		return fmt.Errorf("testvectors: bloom.json holds no filters")
+	}
+	for _, v := range vectors {
+		empty, err := json.Marshal(map[string]any{
+			"bits": base64.StdEncoding.EncodeToString(make([]byte, (v.M+7)/8)),
+			"m":    v.M,
+			"k":    v.K,
+		})
+		if err != nil {
+			return fmt.Errorf("testvectors: bloom %s: %w", v.Name, err)
+		}
+		var filter notary.BloomFilter
+		if err := json.Unmarshal(empty, &filter); err != nil {
+			return fmt.Errorf("testvectors: bloom %s: %w", v.Name, err)
+		}
+		for _, h := range v.Added {
+			filter.Add(h)
+		}
+		data, err := json.Marshal(&filter)
+		if err != nil {
+			return fmt.Errorf("testvectors: bloom %s: %w", v.Name, err)
+		}
+		var got struct {
+			Bits string `json:"bits"`
+		}
+		if err := json.Unmarshal(data, &got); err != nil {
+			return fmt.Errorf("testvectors: bloom %s: %w", v.Name, err)
+		}
+		if got.Bits != v.Bits {
+			return fmt.Errorf("notary bloom compat: filter %s: want bits %s, got %s", v.Name, v.Bits, got.Bits)
+		}
+		for _, h := range v.Added {
+			if !filter.MayExist(h) {
+				return fmt.Errorf("notary bloom compat: filter %s rejects added hash %q", v.Name, h)
+			}
+		}
+		for _, h := range v.Absent {
+			if filter.MayExist(h) {
+				return fmt.Errorf("notary bloom compat: filter %s accepts absent hash %s", v.Name, h)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package testvectors
+
+import "testing"
+
+func TestCheckCompat(t *testing.T) {
+	if err := CheckCompat(); err != nil {
+		t.Fatal(err)
+	}
+}